@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"seattle-info-platform/internal/audit"
+	"seattle-info-platform/internal/platform/auth"
+	"seattle-info-platform/internal/platform/problem"
+)
+
+// auditActor must run after auth.Authorize. It stores the caller's account
+// and address in the request context, where the services' audit records
+// find them.
+func (a *app) auditActor(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actor := audit.Actor{ID: "unknown"}
+		if account, ok := auth.AccountFromContext(r.Context()); ok {
+			actor = audit.Actor{ID: account.ID, Email: account.Email, Role: string(account.Role)}
+		}
+		actor.IP = a.clientIP.IP(r)
+		next.ServeHTTP(w, r.WithContext(audit.NewContext(r.Context(), actor)))
+	})
+}
+
+// adminListAuditHandler serves GET /admin/audit. The target, actor, action
+// and since parameters filter the entries; see audit.Filter.
+func (a *app) adminListAuditHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	since, err := parseTimeParam(query, "since")
+	if err != nil {
+		problem.Error(w, r, http.StatusBadRequest, problem.CodeInvalidQuery, "Invalid filter: "+err.Error())
+		return
+	}
+	page, ok := parsePage(w, r, auditPages)
+	if !ok {
+		return
+	}
+	entries := a.audit.Query(audit.Filter{
+		Target: query.Get("target"),
+		Actor:  query.Get("actor"),
+		Action: query.Get("action"),
+		Since:  since,
+	})
+	writePage(w, r, auditPages, page, entries)
+}
+
+// AuditVerifyResponse reports the state of the audit log hash chain.
+type AuditVerifyResponse struct {
+	Valid   bool   `json:"valid"`
+	Entries int    `json:"entries"`
+	Error   string `json:"error,omitempty"`
+}
+
+// adminVerifyAuditHandler serves GET /admin/audit/verify, which rereads the
+// audit log and checks its hash chain.
+func (a *app) adminVerifyAuditHandler(w http.ResponseWriter, r *http.Request) {
+	n, err := a.audit.Verify()
+	resp := AuditVerifyResponse{Valid: err == nil, Entries: n}
+	switch {
+	case errors.Is(err, audit.ErrTampered):
+		slog.ErrorContext(r.Context(), "audit log verification failed", "err", err)
+		resp.Error = err.Error()
+	case err != nil:
+		internalError(w, r, "Failed to verify audit log", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
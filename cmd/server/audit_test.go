@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"seattle-info-platform/internal/audit"
+	"seattle-info-platform/internal/listing"
+	"seattle-info-platform/internal/platform/database"
+	"seattle-info-platform/internal/user"
+	"seattle-info-platform/pkg/pagination"
+)
+
+func TestAdminListAuditPages(t *testing.T) {
+	auditLog, err := audit.Open("", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"u1", "u2", "u3", "u4", "u5"} {
+		if err := auditLog.Record(context.Background(), audit.Event{Action: "user.approve", TargetType: audit.TargetUser, TargetID: id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	a := newApp(database.NewMemoryStore(), auditLog)
+
+	// The default sort is -seq, so the newest entries come first.
+	var seqs []int64
+	target := "/admin/audit?page_size=2"
+	for pages := 0; pages < 5; pages++ {
+		w := call(a.adminListAuditHandler, "GET", target, "", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s: status %d: %s", target, w.Code, w.Body)
+		}
+		var env pagination.Envelope[audit.Entry]
+		if err := json.Unmarshal(w.Body.Bytes(), &env); err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range env.Data {
+			seqs = append(seqs, e.Seq)
+		}
+		if env.Pagination.NextCursor == "" {
+			break
+		}
+		target = "/admin/audit?page_size=2&cursor=" + env.Pagination.NextCursor
+	}
+	want := []int64{5, 4, 3, 2, 1}
+	if len(seqs) != len(want) {
+		t.Fatalf("got seqs %v, want %v", seqs, want)
+	}
+	for i := range want {
+		if seqs[i] != want[i] {
+			t.Fatalf("got seqs %v, want %v", seqs, want)
+		}
+	}
+
+	w := call(a.adminListAuditHandler, "GET", "/admin/audit?sort=seq&target=user:u2", "", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("filtered list: status %d: %s", w.Code, w.Body)
+	}
+	var env pagination.Envelope[audit.Entry]
+	if err := json.Unmarshal(w.Body.Bytes(), &env); err != nil {
+		t.Fatal(err)
+	}
+	if len(env.Data) != 1 || env.Data[0].TargetID != "u2" {
+		t.Errorf("filter target=user:u2 returned %+v", env.Data)
+	}
+}
+
+// TestMutationsFailClosed checks that no admin mutation is stored when its
+// audit entry cannot be written.
+func TestMutationsFailClosed(t *testing.T) {
+	ctx := context.Background()
+	store := database.NewMemoryStore()
+	if err := seedDemoData(ctx, store); err != nil {
+		t.Fatal(err)
+	}
+	auditLog, err := audit.Open("", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auditLog.Close() // every Record now fails with ErrClosed
+	a := newApp(store, auditLog)
+
+	for _, tc := range []struct {
+		name       string
+		h          http.HandlerFunc
+		method, id string
+		target     string
+		body       string
+	}{
+		{"approve user", a.adminApproveUserHandler, "POST", "user1", "/", ""},
+		{"change role", a.adminChangeUserRoleHandler, "PUT", "user2", "/", `{"role":"moderator"}`},
+		{"listing status", a.adminUpdateListingStatusHandler, "PUT", "listing1", "/", `{"status":"active"}`},
+		{"create category", a.adminCreateCategoryHandler, "POST", "", "/", `{"name":"Books"}`},
+		{"update category", a.adminUpdateCategoryHandler, "PUT", "cat1", "/", `{"name":"Gadgets"}`},
+		{"delete category", a.adminDeleteCategoryHandler, "DELETE", "cat1", "/?reassign_to=cat2", ""},
+	} {
+		if w := call(tc.h, tc.method, tc.target, tc.id, tc.body); w.Code != http.StatusInternalServerError {
+			t.Errorf("%s: status %d, want 500: %s", tc.name, w.Code, w.Body)
+		}
+	}
+
+	if u, _ := store.Users().Get(ctx, "user1"); u.Status != user.StatusPendingApproval {
+		t.Errorf("user1 status = %q, want unchanged", u.Status)
+	}
+	if u, _ := store.Users().Get(ctx, "user2"); u.Role != user.RoleUser {
+		t.Errorf("user2 role = %q, want unchanged", u.Role)
+	}
+	if l, _ := store.Listings().Get(ctx, "listing1"); l.Status != listing.StatusPendingApproval || l.CategoryID != "cat1" {
+		t.Errorf("listing1 = %q in %s, want unchanged", l.Status, l.CategoryID)
+	}
+	categories, _ := store.Categories().List(ctx)
+	if len(categories) != 2 || categories[0].Name != "Electronics" {
+		t.Errorf("categories = %+v, want the two seeded ones unchanged", categories)
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"seattle-info-platform/internal/category"
+	"seattle-info-platform/internal/platform/database"
+	"seattle-info-platform/internal/platform/problem"
+
+	"github.com/google/uuid" // For generating new category IDs
+)
+
+// categoryWithCounts is a category as returned by the list endpoint.
+type categoryWithCounts struct {
+	category.Category
+	ListingCounts category.ListingCounts `json:"listing_counts"`
+}
+
+func (a *app) adminListCategoriesHandler(w http.ResponseWriter, r *http.Request) {
+	page, ok := parsePage(w, r, categoryPages)
+	if !ok {
+		return
+	}
+	categories, err := a.store.Categories().List(r.Context())
+	if err != nil {
+		internalError(w, r, "Failed to list categories", err)
+		return
+	}
+	counts, err := a.store.Categories().ListingCounts(r.Context())
+	if err != nil {
+		internalError(w, r, "Failed to list categories", err)
+		return
+	}
+
+	result := make([]categoryWithCounts, 0, len(categories))
+	for _, c := range categories {
+		result = append(result, categoryWithCounts{Category: c, ListingCounts: counts[c.ID]})
+	}
+
+	writePage(w, r, categoryPages, page, result)
+}
+
+func (a *app) adminCategoryTreeHandler(w http.ResponseWriter, r *http.Request) {
+	categories, err := a.store.Categories().List(r.Context())
+	if err != nil {
+		internalError(w, r, "Failed to list categories", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(category.BuildTree(categories)); err != nil {
+		internalError(w, r, "Failed to encode categories", err)
+	}
+}
+
+// AdminCreateCategoryRequest defines the expected body for creating a category
+type AdminCreateCategoryRequest struct {
+	Name             string `json:"name" validate:"required,max=100"`
+	Description      string `json:"description,omitempty" validate:"max=1000"`
+	ParentCategoryID string `json:"parent_category_id,omitempty" validate:"max=64"`
+}
+
+// writeHierarchyError reports a rejected parent assignment or slug clash. It
+// returns false if err is neither.
+func writeHierarchyError(w http.ResponseWriter, r *http.Request, err error) bool {
+	switch {
+	case errors.Is(err, category.ErrParentNotFound):
+		invalidField(w, r, codeParentNotFound, "parent_category_id", "not_found", "Parent category not found")
+	case errors.Is(err, category.ErrCycle):
+		problem.Error(w, r, http.StatusConflict, codeCategoryCycle, "A category cannot be moved below itself or one of its subcategories")
+	case errors.Is(err, category.ErrTooDeep):
+		problem.Write(w, r, problem.New(http.StatusConflict, codeCategoryTooDeep,
+			fmt.Sprintf("Categories cannot be nested more than %d levels deep", category.MaxDepth)).
+			With("max_depth", category.MaxDepth))
+	case errors.Is(err, category.ErrDuplicateSlug):
+		problem.Error(w, r, http.StatusConflict, codeSlugConflict, "Could not reserve a unique slug for the category, please retry")
+	default:
+		return false
+	}
+	slog.InfoContext(r.Context(), "category hierarchy change refused", "err", err)
+	return true
+}
+
+func (a *app) adminCreateCategoryHandler(w http.ResponseWriter, r *http.Request) {
+	var req AdminCreateCategoryRequest
+	if !decodeBody(w, r, &req, nil) {
+		return
+	}
+
+	newCategory := category.Category{
+		ID:               uuid.New().String(), // Generate new UUID for ID
+		Name:             strings.TrimSpace(req.Name),
+		Description:      req.Description,
+		ParentCategoryID: req.ParentCategoryID,
+	}
+
+	err := a.categories.Create(r.Context(), &newCategory)
+	if writeHierarchyError(w, r, err) {
+		return
+	}
+	if err != nil {
+		internalError(w, r, "Failed to create category "+newCategory.Name, err)
+		return
+	}
+	slog.InfoContext(r.Context(), "category created", "category_id", newCategory.ID, "name", newCategory.Name)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(newCategory); err != nil {
+		slog.ErrorContext(r.Context(), "encode new category", "err", err)
+		// Already sent 201, so can't send new error header easily.
+	}
+}
+
+// AdminUpdateCategoryRequest defines the expected body for updating a
+// category. Omitted fields are left unchanged.
+type AdminUpdateCategoryRequest struct {
+	Name        *string `json:"name,omitempty" validate:"min=1,max=100"`
+	Description *string `json:"description,omitempty" validate:"max=1000"`
+	// ParentCategoryID moves the category, with its whole subtree, below
+	// another category. An empty string makes it top-level.
+	ParentCategoryID *string `json:"parent_category_id,omitempty" validate:"max=64"`
+}
+
+func (a *app) adminUpdateCategoryHandler(w http.ResponseWriter, r *http.Request) {
+	categoryId := r.PathValue("id")
+	var req AdminUpdateCategoryRequest
+	if !decodeBody(w, r, &req, nil) {
+		return
+	}
+
+	c, err := a.categories.Update(r.Context(), categoryId, func(c *category.Category) error {
+		if req.Name != nil {
+			c.Name = strings.TrimSpace(*req.Name)
+		}
+		if req.Description != nil {
+			c.Description = *req.Description
+		}
+		if req.ParentCategoryID != nil {
+			c.ParentCategoryID = *req.ParentCategoryID
+		}
+		return nil
+	})
+	if writeHierarchyError(w, r, err) {
+		return
+	}
+	if errors.Is(err, database.ErrNotFound) {
+		problem.Error(w, r, http.StatusNotFound, codeCategoryNotFound, "Category not found")
+		return
+	}
+	if err != nil {
+		internalError(w, r, "Failed to update category", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(c)
+	slog.InfoContext(r.Context(), "category updated", "category_id", categoryId)
+}
+
+func (a *app) adminDeleteCategoryHandler(w http.ResponseWriter, r *http.Request) {
+	categoryId := r.PathValue("id")
+	reassignTo := r.URL.Query().Get("reassign_to")
+	moved, err := a.categories.Delete(r.Context(), categoryId, reassignTo)
+	switch {
+	case errors.Is(err, database.ErrNotFound):
+		problem.Error(w, r, http.StatusNotFound, codeCategoryNotFound, "Category not found")
+		return
+	case errors.Is(err, database.ErrCategoryInUse):
+		slog.InfoContext(r.Context(), "category delete refused", "category_id", categoryId, "err", err)
+		problem.Error(w, r, http.StatusConflict, codeCategoryInUse, "Category still has listings; pass reassign_to to move them to another category")
+		return
+	case errors.Is(err, database.ErrInvalidReassignment):
+		problem.Error(w, r, http.StatusBadRequest, codeInvalidReassignment, "reassign_to must name another existing category")
+		return
+	case err != nil:
+		internalError(w, r, "Failed to delete category", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"id":                  categoryId,
+		"reassigned_to":       reassignTo,
+		"reassigned_listings": moved,
+	})
+	slog.InfoContext(r.Context(), "category deleted", "category_id", categoryId, "reassigned_to", reassignTo, "reassigned_listings", moved)
+}
+
+// categoryBySlugHandler serves GET /categories/by-slug/{slug}. Slugs a
+// category had before a rename redirect permanently to its current slug.
+func (a *app) categoryBySlugHandler(w http.ResponseWriter, r *http.Request) {
+	s := r.PathValue("slug")
+	categories, err := a.store.Categories().List(r.Context())
+	if err != nil {
+		internalError(w, r, "Failed to load category", err)
+		return
+	}
+	c, current := category.FindBySlug(categories, s)
+	if c == nil {
+		problem.Error(w, r, http.StatusNotFound, codeCategoryNotFound, "Category not found")
+		return
+	}
+	if !current {
+		// A relative Location keeps the /api/v1 prefix that StripPrefix hid
+		// from r.URL, which http.Redirect would resolve against.
+		w.Header().Set("Location", url.PathEscape(c.Slug))
+		w.WriteHeader(http.StatusMovedPermanently)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(c); err != nil {
+		internalError(w, r, "Failed to encode category", err)
+	}
+}
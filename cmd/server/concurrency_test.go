@@ -0,0 +1,292 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"seattle-info-platform/internal/audit"
+	"seattle-info-platform/internal/category"
+	"seattle-info-platform/internal/listing"
+	"seattle-info-platform/internal/platform/auth"
+	"seattle-info-platform/internal/platform/database"
+	"seattle-info-platform/internal/user"
+	"seattle-info-platform/pkg/pagination"
+)
+
+// These tests are meant to be run with -race. They call the admin handlers
+// directly, bypassing authentication, from many goroutines at once. Every
+// request carries the seeded admin account.
+
+const workers = 32
+
+func call(h http.HandlerFunc, method, target, id, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	r = r.WithContext(auth.NewAccountContext(r.Context(), &user.User{ID: "admin1", Role: user.RoleAdmin, Status: user.StatusActive}))
+	r = r.WithContext(audit.NewContext(r.Context(), audit.Actor{ID: "admin1", Role: string(user.RoleAdmin)}))
+	if id != "" {
+		r.SetPathValue("id", id)
+	}
+	w := httptest.NewRecorder()
+	h(w, r)
+	return w
+}
+
+func hammer(t *testing.T, store database.Store, auditLog *audit.Log) {
+	t.Helper()
+	ctx := context.Background()
+	if err := seedDemoData(ctx, store); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	a := newApp(store, auditLog)
+
+	var approved, rejected, listingUpdates atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(6)
+		go func() {
+			defer wg.Done()
+			switch w := call(a.adminApproveUserHandler, http.MethodPost, "/admin/users/user1/approve", "user1", ""); w.Code {
+			case http.StatusOK:
+				approved.Add(1)
+			case http.StatusConflict:
+				rejected.Add(1)
+			default:
+				t.Errorf("approve: unexpected status %d: %s", w.Code, w.Body)
+			}
+		}()
+		go func(i int) {
+			defer wg.Done()
+			role := []user.UserRole{user.RoleUser, user.RoleModerator, user.RoleAdmin}[i%3]
+			body := fmt.Sprintf(`{"role":%q}`, role)
+			if w := call(a.adminChangeUserRoleHandler, http.MethodPut, "/admin/users/user2/role", "user2", body); w.Code != http.StatusOK {
+				t.Errorf("change role: unexpected status %d: %s", w.Code, w.Body)
+			}
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			// Toggling between removed and restored races with itself; a
+			// request that loses finds the listing already in the target
+			// status and is refused.
+			status := []listing.ListingStatus{listing.StatusActive, listing.StatusAdminRemoved}[i%2]
+			body := fmt.Sprintf(`{"status":%q}`, status)
+			switch w := call(a.adminUpdateListingStatusHandler, http.MethodPut, "/admin/listings/listing2/status", "listing2", body); w.Code {
+			case http.StatusOK:
+				listingUpdates.Add(1)
+			case http.StatusConflict:
+			default:
+				t.Errorf("update listing status: unexpected status %d: %s", w.Code, w.Body)
+			}
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			body := fmt.Sprintf(`{"name":"Category %d"}`, i)
+			if w := call(a.adminCreateCategoryHandler, http.MethodPost, "/admin/categories", "", body); w.Code != http.StatusCreated {
+				t.Errorf("create category: unexpected status %d: %s", w.Code, w.Body)
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			for _, h := range []http.HandlerFunc{a.adminListUsersHandler, a.adminListListingsHandler, a.adminListCategoriesHandler} {
+				if w := call(h, http.MethodGet, "/admin/list", "", ""); w.Code != http.StatusOK {
+					t.Errorf("list: unexpected status %d: %s", w.Code, w.Body)
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := store.Users().Get(ctx, "user1"); err != nil {
+				t.Errorf("get user: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := approved.Load(); got != 1 {
+		t.Errorf("user1 approved %d times, want exactly once", got)
+	}
+	if got := rejected.Load(); got != workers-1 {
+		t.Errorf("%d approvals refused, want %d", got, workers-1)
+	}
+
+	u, err := store.Users().Get(ctx, "user1")
+	if err != nil || u.Status != user.StatusActive {
+		t.Errorf("user1 = %+v, %v; want active", u, err)
+	}
+	l, err := store.Listings().Get(ctx, "listing2")
+	if err != nil || (l.Status != listing.StatusActive && l.Status != listing.StatusAdminRemoved) {
+		t.Errorf("listing2 = %+v, %v; want active or admin_removed", l, err)
+	}
+	// The incrementally maintained counts must match a full scan.
+	listings, err := store.Listings().List(ctx, database.ListingFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	scanned := make(map[string]map[listing.ListingStatus]int)
+	for _, l := range listings {
+		if scanned[l.CategoryID] == nil {
+			scanned[l.CategoryID] = make(map[listing.ListingStatus]int)
+		}
+		scanned[l.CategoryID][l.Status]++
+	}
+	counts, err := store.Categories().ListingCounts(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id, c := range counts {
+		if !maps.Equal(c.Own, scanned[id]) && (len(c.Own) != 0 || len(scanned[id]) != 0) {
+			t.Errorf("category %s counts %v, scan found %v", id, c.Own, scanned[id])
+		}
+	}
+	u, err = store.Users().Get(ctx, "user2")
+	if err != nil || !u.Role.IsValid() {
+		t.Errorf("user2 = %+v, %v; want a valid role", u, err)
+	}
+
+	w := call(a.adminListCategoriesHandler, http.MethodGet, "/admin/categories?page_size=100", "", "")
+	var page pagination.Envelope[category.Category]
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatalf("decode categories: %v", err)
+	}
+	categories := page.Data
+	if len(categories) != 2+workers {
+		t.Errorf("got %d categories, want %d", len(categories), 2+workers)
+	}
+	seen := make(map[string]bool)
+	for _, c := range categories {
+		if seen[c.ID] {
+			t.Errorf("duplicate category id %s", c.ID)
+		}
+		seen[c.ID] = true
+	}
+
+	// Every successful mutation, and nothing else, must be in an unbroken
+	// audit chain.
+	for action, want := range map[string]int{
+		"user.approve":       1,
+		"user.change_role":   workers,
+		"listing.set_status": int(listingUpdates.Load()),
+		"category.create":    workers,
+	} {
+		if got := len(auditLog.Query(audit.Filter{Action: action})); got != want {
+			t.Errorf("audit log has %d %s entries, want %d", got, action, want)
+		}
+	}
+	if n, err := auditLog.Verify(); err != nil || n != 1+2*workers+int(listingUpdates.Load()) {
+		t.Errorf("audit log verified %d entries: %v", n, err)
+	}
+}
+
+func TestConcurrentAdminMutationsMemoryStore(t *testing.T) {
+	auditLog, err := audit.Open("", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hammer(t, database.NewMemoryStore(), auditLog)
+}
+
+func TestConcurrentAdminMutationsFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db.json")
+	m, err := database.NewMigrator(path, database.Migrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	store, err := database.OpenFile(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	auditPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	auditLog, err := audit.Open(auditPath, []byte("test key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hammer(t, store, auditLog)
+	if err := store.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if err := auditLog.Close(); err != nil {
+		t.Fatalf("close audit log: %v", err)
+	}
+	if _, err := audit.Open(auditPath, []byte("test key")); err != nil {
+		t.Errorf("reopen audit log: %v", err)
+	}
+
+	// Everything written concurrently must have reached the file.
+	reopened, err := database.OpenFile(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	categories, err := reopened.Categories().List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(categories) != 2+workers {
+		t.Errorf("reopened store has %d categories, want %d", len(categories), 2+workers)
+	}
+}
+
+// TestConcurrentRateLimit sends a burst from one account, and from many
+// addresses, through the admin rate limit at once. The rate is so low that
+// no token is refilled during the test.
+func TestConcurrentRateLimit(t *testing.T) {
+	const burst, maxClients = 10, 8
+	a := newApp(database.NewMemoryStore(), nil)
+	limiter := newLimiter(0.001, burst, maxClients)
+	ok := limiter.Middleware(a.accountKey)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	var allowed, limited atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := call(ok.ServeHTTP, "GET", "/admin/users", "", "")
+			switch w.Code {
+			case http.StatusOK:
+				allowed.Add(1)
+			case http.StatusTooManyRequests:
+				limited.Add(1)
+				if w.Header().Get("Retry-After") == "" {
+					t.Error("429 without Retry-After")
+				}
+			default:
+				t.Errorf("status %d", w.Code)
+			}
+		}()
+	}
+	wg.Wait()
+	if allowed.Load() != burst || limited.Load() != workers-burst {
+		t.Errorf("allowed %d and limited %d requests, want %d and %d", allowed.Load(), limited.Load(), burst, workers-burst)
+	}
+
+	// Unauthenticated callers are keyed by address, and the limiter never
+	// tracks more than maxClients of them.
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r := httptest.NewRequest("GET", "/categories/by-slug/x", nil)
+			r.RemoteAddr = fmt.Sprintf("192.0.2.%d:1234", i)
+			w := httptest.NewRecorder()
+			ok.ServeHTTP(w, r)
+			if w.Code != http.StatusOK {
+				t.Errorf("first request from %s: status %d", r.RemoteAddr, w.Code)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := limiter.Len(); n > maxClients {
+		t.Errorf("limiter tracks %d clients, want at most %d", n, maxClients)
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"seattle-info-platform/pkg/config"
+)
+
+// loadConfig parses the flags of a subcommand and returns the effective
+// configuration together with the flag set, whose Args are the remaining
+// positional arguments.
+func loadConfig(command string, args []string) (*config.Config, *flag.FlagSet, error) {
+	fs := flag.NewFlagSet(command, flag.ContinueOnError)
+	cfg, err := config.Load(fs, args, os.LookupEnv)
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		return nil, fs, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	return cfg, fs, err
+}
+
+// runConfig implements `server config print [flags]`.
+func runConfig(args []string, stdout io.Writer) error {
+	if len(args) == 0 || args[0] != "print" {
+		return errors.New("usage: server config print [flags]")
+	}
+	cfg, fs, err := loadConfig("config print", args[1:])
+	if err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("config print: unexpected arguments %q", fs.Args())
+	}
+	return cfg.Print(stdout)
+}
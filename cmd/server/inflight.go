@@ -0,0 +1,56 @@
+package main
+
+import (
+	"net/http"
+	"sync"
+)
+
+// inFlight counts the requests being handled, so that shutdown can wait for
+// every handler to return before closing what handlers use. http.Server's
+// Close, unlike Shutdown, returns while handlers are still running.
+type inFlight struct {
+	mu   sync.Mutex
+	idle *sync.Cond
+	n    int
+}
+
+func newInFlight() *inFlight {
+	f := &inFlight{}
+	f.idle = sync.NewCond(&f.mu)
+	return f
+}
+
+// Middleware counts the requests served by next.
+func (f *inFlight) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		f.n++
+		f.mu.Unlock()
+		defer f.done()
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (f *inFlight) done() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.n--; f.n == 0 {
+		f.idle.Broadcast()
+	}
+}
+
+// Len returns the number of requests being handled.
+func (f *inFlight) Len() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.n
+}
+
+// Wait blocks until no request is being handled.
+func (f *inFlight) Wait() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for f.n > 0 {
+		f.idle.Wait()
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestInFlightOutlivesClose checks that Wait covers handlers that keep
+// running after http.Server.Close has returned.
+func TestInFlightOutlivesClose(t *testing.T) {
+	handlers := newInFlight()
+	entered, release := make(chan struct{}), make(chan struct{})
+	finished := false
+	srv := httptest.NewServer(handlers.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-release
+		finished = true
+	})))
+
+	go http.Get(srv.URL)
+	<-entered
+	srv.Config.Close()
+	if n := handlers.Len(); n != 1 {
+		t.Fatalf("Len after Close = %d, want 1", n)
+	}
+
+	waited := make(chan struct{})
+	go func() {
+		handlers.Wait()
+		close(waited)
+	}()
+	select {
+	case <-waited:
+		t.Fatal("Wait returned while a handler was running")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	<-waited
+	if !finished || handlers.Len() != 0 {
+		t.Errorf("after Wait: finished = %v, Len = %d", finished, handlers.Len())
+	}
+	srv.Close()
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"seattle-info-platform/internal/listing"
+	"seattle-info-platform/internal/platform/auth"
+	"seattle-info-platform/internal/platform/database"
+	"seattle-info-platform/internal/platform/problem"
+	"seattle-info-platform/internal/platform/search"
+	"seattle-info-platform/internal/user"
+)
+
+func (a *app) adminListListingsHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := database.ListingFilter{
+		Status:     listing.ListingStatus(query.Get("status")),
+		CategoryID: query.Get("category_id"),
+	}
+	if filter.Status != "" && !filter.Status.IsValid() {
+		problem.Error(w, r, http.StatusBadRequest, problem.CodeInvalidQuery, fmt.Sprintf("Invalid filter: invalid status %q", filter.Status))
+		return
+	}
+	if v := query.Get("include_descendants"); v != "" {
+		include, err := strconv.ParseBool(v)
+		if err != nil {
+			problem.Error(w, r, http.StatusBadRequest, problem.CodeInvalidQuery, "include_descendants must be true or false")
+			return
+		}
+		filter.IncludeDescendants = include
+	}
+	if query.Has("q") {
+		a.searchListings(w, r, query.Get("q"), filter)
+		return
+	}
+
+	page, ok := parsePage(w, r, listingPages)
+	if !ok {
+		return
+	}
+
+	resultListings, err := a.store.Listings().List(r.Context(), filter)
+	if err != nil {
+		internalError(w, r, "Failed to list listings", err)
+		return
+	}
+
+	writePage(w, r, listingPages, page, resultListings)
+}
+
+// searchListings answers a listing list request that has a q parameter with
+// ranked full-text matches.
+func (a *app) searchListings(w http.ResponseWriter, r *http.Request, q string, filter database.ListingFilter) {
+	page, ok := parsePage(w, r, listingMatchPages)
+	if !ok {
+		return
+	}
+	parsed, err := search.ParseQuery(q)
+	if err != nil {
+		problem.Error(w, r, http.StatusBadRequest, codeInvalidSearch, err.Error())
+		return
+	}
+	matches, err := a.store.Listings().Search(r.Context(), parsed, filter)
+	if err != nil {
+		internalError(w, r, "Failed to search listings", err)
+		return
+	}
+	writePage(w, r, listingMatchPages, page, matches)
+}
+
+// AdminUpdateListingStatusRequest defines the expected body for updating listing status
+type AdminUpdateListingStatusRequest struct {
+	Status          listing.ListingStatus `json:"status" validate:"required"`
+	RejectionReason string                `json:"rejectionReason,omitempty" validate:"max=500"`
+}
+
+// listingActor maps the caller's role to the actor used by the listing
+// transition rules.
+func listingActor(r *http.Request) (listing.Actor, bool) {
+	account, ok := auth.AccountFromContext(r.Context())
+	if !ok {
+		return "", false
+	}
+	switch account.Role {
+	case user.RoleAdmin:
+		return listing.ActorAdmin, true
+	case user.RoleModerator:
+		return listing.ActorModerator, true
+	}
+	return "", false
+}
+
+func (a *app) adminUpdateListingStatusHandler(w http.ResponseWriter, r *http.Request) {
+	listingId := r.PathValue("id")
+	actor, ok := listingActor(r)
+	if !ok {
+		problem.Error(w, r, http.StatusForbidden, problem.CodeForbidden, "Your role cannot change listing statuses")
+		return
+	}
+
+	var req AdminUpdateListingStatusRequest
+	if !decodeBody(w, r, &req, listingStatusCodes) {
+		return
+	}
+
+	l, err := a.listings.SetStatus(r.Context(), listingId, req.Status, actor, req.RejectionReason)
+	var transitionErr *listing.TransitionError
+	switch {
+	case errors.Is(err, database.ErrNotFound):
+		problem.Error(w, r, http.StatusNotFound, codeListingNotFound, "Listing not found")
+		return
+	case errors.As(err, &transitionErr):
+		slog.InfoContext(r.Context(), "listing transition refused", "listing_id", listingId, "err", err)
+		problem.Write(w, r, problem.New(http.StatusConflict, codeInvalidListingTransit,
+			fmt.Sprintf("Listing status %q cannot change to %q.", transitionErr.From, transitionErr.To)).
+			With("current_status", transitionErr.From).
+			With("allowed_statuses", transitionErr.Allowed()))
+		return
+	case errors.Is(err, listing.ErrReasonRequired):
+		invalidField(w, r, codeRejectionReasonMissing, "rejectionReason", "required", "rejectionReason is required when rejecting a listing")
+		return
+	case err != nil:
+		internalError(w, r, "Failed to update listing status", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(l)
+	slog.InfoContext(r.Context(), "listing status updated", "listing_id", listingId, "status", req.Status)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"seattle-info-platform/internal/listing"
+	"seattle-info-platform/internal/platform/database"
+	"seattle-info-platform/internal/platform/logging"
+	"seattle-info-platform/internal/platform/metrics"
+	"seattle-info-platform/internal/user"
+)
+
+// newMetrics returns the registry served by metricsRoutes, with the HTTP metrics
+// and the domain gauges read from store on every scrape.
+func newMetrics(store database.Store) (*metrics.Registry, *metrics.HTTP) {
+	reg := metrics.NewRegistry()
+	httpMetrics := metrics.NewHTTP(reg, func(r *http.Request) string {
+		return logging.RoutePattern(r.Context())
+	})
+
+	reg.NewGaugeFunc("seattle_users", "User accounts, by status.", "status", func() (map[string]float64, error) {
+		counts, err := store.Users().CountByStatus(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		// Every status is exported, even when no user is in it.
+		values := make(map[string]float64, len(user.Transitions))
+		for status := range user.Transitions {
+			values[string(status)] = float64(counts[status])
+		}
+		return values, nil
+	})
+	reg.NewGaugeFunc("seattle_listings", "Listings, by status.", "status", func() (map[string]float64, error) {
+		counts, err := store.Listings().CountByStatus(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		values := make(map[string]float64, len(listing.Transitions))
+		for status := range listing.Transitions {
+			values[string(status)] = float64(counts[status])
+		}
+		return values, nil
+	})
+	return reg, httpMetrics
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"seattle-info-platform/internal/platform/auth"
+	"seattle-info-platform/internal/platform/database"
+	"seattle-info-platform/pkg/config"
+)
+
+// TestMetricsListener checks that metrics are only served by
+// metricsRoutes, never on the public listener.
+func TestMetricsListener(t *testing.T) {
+	cfg := config.Default()
+	keys := auth.NewKeyCache(auth.FileKeySource{Path: filepath.Join(t.TempDir(), "missing.json")})
+	a := newApp(database.NewMemoryStore(), nil)
+
+	w := httptest.NewRecorder()
+	a.routes(cfg, auth.NewVerifier(cfg.Auth.FirebaseProjectID, keys)).ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("public /metrics status = %d, want 404", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	a.metricsRoutes().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "seattle_users") {
+		t.Errorf("metrics listener = %d %q", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"seattle-info-platform/internal/platform/database"
+)
+
+// runMigrate implements `server migrate [flags] up|down|status`.
+func runMigrate(args []string, stdout io.Writer) error {
+	cfg, fs, err := loadConfig("migrate", args)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: server migrate [flags] up|down|status")
+	}
+	return migrateDatabase(cfg.Database.Path, fs.Arg(0), stdout)
+}
+
+// migrateDatabase runs one migrate command against the database file at path.
+func migrateDatabase(path, command string, stdout io.Writer) error {
+	m, err := database.NewMigrator(path, database.Migrations)
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "up":
+		applied, err := m.Up()
+		for _, mig := range applied {
+			fmt.Fprintf(stdout, "applied %04d_%s\n", mig.Version, mig.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Fprintln(stdout, "schema is up to date")
+		}
+	case "down":
+		mig, err := m.Down()
+		if errors.Is(err, database.ErrNoMigrationApplied) {
+			fmt.Fprintln(stdout, "no migration to roll back")
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "rolled back %04d_%s\n", mig.Version, mig.Name)
+	case "status":
+		statuses, err := m.Status()
+		if err != nil {
+			return err
+		}
+		tw := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "VERSION\tNAME\tSTATE\tAPPLIED AT")
+		for _, st := range statuses {
+			state, appliedAt := "pending", ""
+			switch {
+			case st.Dirty:
+				state = "dirty"
+			case st.Modified:
+				state = "modified"
+			case st.Applied:
+				state = "applied"
+			}
+			if st.Applied {
+				appliedAt = st.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(tw, "%04d\t%s\t%s\t%s\n", st.Version, st.Name, state, appliedAt)
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("migrate: unknown command %q; expected up, down or status", command)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"seattle-info-platform/internal/platform/database"
+)
+
+func TestMigrateStatusOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db.json")
+	last := database.Migrations[len(database.Migrations)-1]
+
+	var out bytes.Buffer
+	for _, command := range []string{"up", "down", "status"} {
+		if err := migrateDatabase(path, command, &out); err != nil {
+			t.Fatalf("migrate %s: %v", command, err)
+		}
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if want := fmt.Sprintf("rolled back %04d_%s", last.Version, last.Name); !strings.Contains(out.String(), want) {
+		t.Errorf("output lacks %q:\n%s", want, out.String())
+	}
+
+	// The status table ends with a header and one row per migration.
+	table := lines[len(lines)-len(database.Migrations)-1:]
+	if fields := strings.Fields(table[0]); strings.Join(fields, " ") != "VERSION NAME STATE APPLIED AT" {
+		t.Errorf("header = %q", table[0])
+	}
+	for i, mig := range database.Migrations {
+		fields := strings.Fields(table[i+1])
+		wantState := "applied"
+		if mig.Version == last.Version {
+			wantState = "pending"
+		}
+		if len(fields) < 3 || fields[0] != fmt.Sprintf("%04d", mig.Version) || fields[1] != mig.Name || fields[2] != wantState {
+			t.Errorf("row %d = %q, want %04d %s %s", i, table[i+1], mig.Version, mig.Name, wantState)
+		}
+		if hasTime := len(fields) == 4; hasTime != (wantState == "applied") {
+			t.Errorf("row %d = %q: applied-at column present = %v", i, table[i+1], hasTime)
+		}
+	}
+
+	if err := migrateDatabase(path, "sideways", &out); err == nil {
+		t.Error("unknown command accepted")
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"seattle-info-platform/internal/audit"
+	"seattle-info-platform/internal/listing"
+	"seattle-info-platform/internal/platform/database"
+	"seattle-info-platform/internal/platform/problem"
+	"seattle-info-platform/internal/user"
+	"seattle-info-platform/pkg/pagination"
+)
+
+// Sortable fields of each list endpoint. pagination.Must checks them when
+// the server starts.
+var (
+	userPages = pagination.Must(pagination.Spec[user.User]{
+		Fields: map[string]func(user.User) any{
+			"email":             func(u user.User) any { return u.Email },
+			"first_name":        func(u user.User) any { return u.FirstName },
+			"last_name":         func(u user.User) any { return u.LastName },
+			"role":              func(u user.User) any { return string(u.Role) },
+			"status":            func(u user.User) any { return string(u.Status) },
+			"registration_date": func(u user.User) any { return u.RegistrationDate },
+			"last_login_date":   func(u user.User) any { return u.LastLoginDate },
+			"created_at":        func(u user.User) any { return u.CreatedAt },
+			"updated_at":        func(u user.User) any { return u.UpdatedAt },
+		},
+		ID:          func(u user.User) string { return u.ID },
+		DefaultSort: []pagination.Order{{Field: "registration_date", Desc: true}},
+	})
+	listingPages = pagination.Must(pagination.Spec[listing.Listing]{
+		Fields: map[string]func(listing.Listing) any{
+			"title":             func(l listing.Listing) any { return l.Title },
+			"status":            func(l listing.Listing) any { return string(l.Status) },
+			"category_id":       func(l listing.Listing) any { return l.CategoryID },
+			"creation_date":     func(l listing.Listing) any { return l.CreationDate },
+			"last_updated_date": func(l listing.Listing) any { return l.LastUpdatedDate },
+			"created_at":        func(l listing.Listing) any { return l.CreatedAt },
+			"updated_at":        func(l listing.Listing) any { return l.UpdatedAt },
+		},
+		ID:          func(l listing.Listing) string { return l.ID },
+		DefaultSort: []pagination.Order{{Field: "created_at", Desc: true}},
+	})
+	// listingMatchPages sorts search results. It offers every listing field
+	// plus relevance, the default.
+	listingMatchPages = pagination.Must(pagination.Spec[database.ListingMatch]{
+		Fields: func() map[string]func(database.ListingMatch) any {
+			fields := map[string]func(database.ListingMatch) any{
+				"relevance": func(m database.ListingMatch) any { return m.Score },
+			}
+			for name, f := range listingPages.Fields {
+				fields[name] = func(m database.ListingMatch) any { return f(m.Listing) }
+			}
+			return fields
+		}(),
+		ID:          func(m database.ListingMatch) string { return m.ID },
+		DefaultSort: []pagination.Order{{Field: "relevance", Desc: true}},
+	})
+	categoryPages = pagination.Must(pagination.Spec[categoryWithCounts]{
+		Fields: map[string]func(categoryWithCounts) any{
+			"name":       func(c categoryWithCounts) any { return c.Name },
+			"slug":       func(c categoryWithCounts) any { return c.Slug },
+			"created_at": func(c categoryWithCounts) any { return c.CreatedAt },
+			"updated_at": func(c categoryWithCounts) any { return c.UpdatedAt },
+		},
+		ID:          func(c categoryWithCounts) string { return c.ID },
+		DefaultSort: []pagination.Order{{Field: "name"}},
+	})
+	auditPages = pagination.Must(pagination.Spec[audit.Entry]{
+		Fields: map[string]func(audit.Entry) any{
+			"seq":  func(e audit.Entry) any { return e.Seq },
+			"time": func(e audit.Entry) any { return e.Time },
+		},
+		ID:          func(e audit.Entry) string { return e.Hash },
+		DefaultSort: []pagination.Order{{Field: "seq", Desc: true}},
+	})
+)
+
+// parsePage reads the paging parameters of r, answering 400 if they are
+// invalid.
+func parsePage[T any](w http.ResponseWriter, r *http.Request, spec pagination.Spec[T]) (pagination.Request, bool) {
+	req, err := spec.Parse(r.URL.Query())
+	if err != nil {
+		problem.Error(w, r, http.StatusBadRequest, problem.CodeInvalidQuery, err.Error())
+		return req, false
+	}
+	return req, true
+}
+
+// writePage writes the requested page of items in the standard envelope.
+func writePage[T any](w http.ResponseWriter, r *http.Request, spec pagination.Spec[T], req pagination.Request, items []T) {
+	env, err := spec.Apply(items, req)
+	if err != nil {
+		problem.Error(w, r, http.StatusBadRequest, problem.CodeInvalidQuery, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(env); err != nil {
+		slog.ErrorContext(r.Context(), "encode page", "err", err)
+	}
+}
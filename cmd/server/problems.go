@@ -0,0 +1,93 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+
+	"seattle-info-platform/internal/platform/problem"
+	"seattle-info-platform/internal/platform/validate"
+)
+
+// Problem codes of the admin API, in addition to the generic ones in package
+// problem. Clients switch on these, so they must never change.
+const (
+	codeUserNotFound           = "user_not_found"
+	codeUserNotPending         = "user_not_pending"
+	codeUserNotActive          = "user_not_active"
+	codeUserNotSuspended       = "user_not_suspended"
+	codeUserInactive           = "user_inactive"
+	codeInvalidUserTransition  = "invalid_user_transition"
+	codeReasonRequired         = "reason_required"
+	codeInvalidRole            = "invalid_role"
+	codeSelfChange             = "self_change_forbidden"
+	codeTargetRoleForbidden    = "target_role_forbidden"
+	codeLastAdmin              = "last_admin"
+	codeListingNotFound        = "listing_not_found"
+	codeInvalidListingStatus   = "invalid_listing_status"
+	codeInvalidListingTransit  = "invalid_listing_transition"
+	codeRejectionReasonMissing = "rejection_reason_required"
+	codeCategoryNotFound       = "category_not_found"
+	codeParentNotFound         = "parent_category_not_found"
+	codeCategoryCycle          = "category_cycle"
+	codeCategoryTooDeep        = "category_too_deep"
+	codeSlugConflict           = "slug_conflict"
+	codeCategoryInUse          = "category_in_use"
+	codeInvalidReassignment    = "invalid_reassignment"
+	codeInvalidSearch          = "invalid_search_query"
+)
+
+// Problem codes for body fields whose value fails its IsValid check. These
+// fields were reported with their own codes before package validate existed,
+// so they keep them instead of the generic validation_failed.
+var (
+	listingStatusCodes = map[string]string{"status": codeInvalidListingStatus}
+	roleCodes          = map[string]string{"role": codeInvalidRole}
+)
+
+// The request bodies decoded with decodeBody. validate.Must checks their
+// tags when the server starts.
+func init() {
+	validate.Must(
+		UserStatusChangeRequest{}, UpdateRoleRequest{},
+		AdminUpdateListingStatusRequest{},
+		AdminCreateCategoryRequest{}, AdminUpdateCategoryRequest{},
+	)
+}
+
+// decodeBody reads the JSON body of r into dst and checks its validate tags.
+// On failure it answers the request and returns false. invalidCodes, which
+// may be nil, maps body fields to the problem code used when their value is
+// invalid.
+func decodeBody(w http.ResponseWriter, r *http.Request, dst any, invalidCodes map[string]string) bool {
+	return checkBody(w, r, validate.DecodeJSON(w, r, dst), invalidCodes)
+}
+
+// checkBody answers the request with p, if any, and reports whether the body
+// was accepted.
+func checkBody(w http.ResponseWriter, r *http.Request, p *problem.Problem, invalidCodes map[string]string) bool {
+	if p == nil {
+		return true
+	}
+	slog.InfoContext(r.Context(), "request body rejected", "err", p)
+	for _, e := range p.Errors {
+		if code, ok := invalidCodes[e.Field]; ok && e.Code == validate.CodeInvalid {
+			p = problem.New(p.Status, code, e.Message).WithErrors(p.Errors...)
+			break
+		}
+	}
+	problem.Write(w, r, p)
+	return false
+}
+
+// invalidField answers a request with a single invalid body field.
+func invalidField(w http.ResponseWriter, r *http.Request, code, field, fieldCode, message string) {
+	problem.Write(w, r, problem.New(http.StatusBadRequest, code, message).
+		WithErrors(problem.FieldError{Field: field, Code: fieldCode, Message: message}))
+}
+
+// internalError logs err and answers with a generic 500, so no internal
+// detail reaches the client.
+func internalError(w http.ResponseWriter, r *http.Request, detail string, err error) {
+	slog.ErrorContext(r.Context(), detail, "err", err)
+	problem.Error(w, r, http.StatusInternalServerError, problem.CodeInternal, detail)
+}
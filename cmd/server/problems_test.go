@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"seattle-info-platform/internal/audit"
+	"seattle-info-platform/internal/platform/auth"
+	"seattle-info-platform/internal/platform/database"
+	"seattle-info-platform/internal/platform/problem"
+	"seattle-info-platform/internal/user"
+)
+
+func TestInvalidValueCodes(t *testing.T) {
+	store := database.NewMemoryStore()
+	if err := seedDemoData(context.Background(), store); err != nil {
+		t.Fatal(err)
+	}
+	auditLog, err := audit.Open("", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := newApp(store, auditLog)
+
+	for _, tc := range []struct {
+		name string
+		h    http.HandlerFunc
+		id   string
+		body string
+		code string
+	}{
+		{"listing status", a.adminUpdateListingStatusHandler, "listing2", `{"status":"bogus"}`, codeInvalidListingStatus},
+		{"listing status with other violations", a.adminUpdateListingStatusHandler, "listing2", `{"status":"bogus","extra":1}`, codeInvalidListingStatus},
+		{"role", a.adminChangeUserRoleHandler, "user2", `{"role":"root"}`, codeInvalidRole},
+		{"missing role", a.adminChangeUserRoleHandler, "user2", `{}`, problem.CodeValidationFailed},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := call(tc.h, http.MethodPut, "/", tc.id, tc.body)
+			var p problem.Problem
+			if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+				t.Fatal(err)
+			}
+			if w.Code != http.StatusBadRequest || p.Code != tc.code {
+				t.Errorf("got %d %s, want 400 %s", w.Code, p.Code, tc.code)
+			}
+		})
+	}
+}
+
+func TestStatusChangeEmptyChunkedBody(t *testing.T) {
+	store := database.NewMemoryStore()
+	if err := seedDemoData(context.Background(), store); err != nil {
+		t.Fatal(err)
+	}
+	auditLog, err := audit.Open("", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := newApp(store, auditLog)
+
+	// A body of unknown length has ContentLength -1, as a chunked request.
+	r := httptest.NewRequest(http.MethodPost, "/admin/users/user1/deactivate", io.MultiReader())
+	if r.ContentLength != -1 {
+		t.Fatalf("ContentLength = %d, want -1", r.ContentLength)
+	}
+	r = r.WithContext(auth.NewAccountContext(r.Context(), &user.User{ID: "admin1", Role: user.RoleAdmin, Status: user.StatusActive}))
+	r = r.WithContext(audit.NewContext(r.Context(), audit.Actor{ID: "admin1", Role: string(user.RoleAdmin)}))
+	r.SetPathValue("id", "user1")
+	w := httptest.NewRecorder()
+	a.adminDeactivateUserHandler(w, r)
+	if w.Code != http.StatusOK {
+		t.Errorf("deactivate with empty chunked body: status %d: %s", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"seattle-info-platform/internal/platform/auth"
+	"seattle-info-platform/internal/platform/ratelimit"
+)
+
+// newLimiter returns the limiter of a route group, or nil, which lets every
+// request through, if its rate is zero.
+func newLimiter(rate float64, burst, maxClients int) *ratelimit.Limiter {
+	if rate == 0 {
+		return nil
+	}
+	return ratelimit.New(rate, burst, maxClients)
+}
+
+// accountKey identifies the caller for rate limiting by account once
+// auth.Authorize has run, so an account keeps its budget across addresses,
+// and by client address before that.
+func (a *app) accountKey(r *http.Request) string {
+	if account, ok := auth.AccountFromContext(r.Context()); ok {
+		return "user:" + account.ID
+	}
+	return a.addressKey(r)
+}
+
+// addressKey identifies the caller by client address.
+func (a *app) addressKey(r *http.Request) string {
+	return "ip:" + a.clientIP.IP(r)
+}
+
+// publicKey identifies callers of the public API, below /api/v1, by client
+// address. Probes, which load balancers send often from few addresses, and
+// the admin routes, which have limits of their own, are not limited.
+func (a *app) publicKey(r *http.Request) string {
+	switch p := r.URL.Path; {
+	case p == "/health", strings.HasPrefix(p, "/health/"), strings.HasPrefix(p, "/admin/"):
+		return ""
+	}
+	return a.addressKey(r)
+}
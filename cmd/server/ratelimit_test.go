@@ -0,0 +1,69 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"seattle-info-platform/internal/platform/auth"
+	"seattle-info-platform/internal/platform/database"
+	"seattle-info-platform/pkg/config"
+)
+
+// TestRateLimitGroups sends requests through the full routes with tiny
+// bursts and a rate too low to refill during the test.
+func TestRateLimitGroups(t *testing.T) {
+	cfg := config.Default()
+	cfg.RateLimit.PublicRate, cfg.RateLimit.PublicBurst = 0.001, 2
+	cfg.RateLimit.AdminIPRate, cfg.RateLimit.AdminIPBurst = 0.001, 3
+	keys := auth.NewKeyCache(auth.FileKeySource{Path: filepath.Join(t.TempDir(), "missing.json")})
+	h := newApp(database.NewMemoryStore(), nil).routes(cfg, auth.NewVerifier(cfg.Auth.FirebaseProjectID, keys))
+
+	get := func(path, remoteAddr, token string) int {
+		r := httptest.NewRequest("GET", path, nil)
+		r.RemoteAddr = remoteAddr
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+	expect := func(what string, got []int, want ...int) {
+		t.Helper()
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("%s: statuses %v, want %v", what, got, want)
+				return
+			}
+		}
+	}
+
+	// Bad tokens are throttled by address before they are verified.
+	var codes []int
+	for i := 0; i < 4; i++ {
+		codes = append(codes, get("/api/v1/admin/users", "192.0.2.1:1000", "not-a-token"))
+	}
+	expect("admin with a bad token", codes, 401, 401, 401, 429)
+
+	// Every public path counts, including unknown ones; admin requests from
+	// the same address do not use up the public budget.
+	codes = nil
+	for _, path := range []string{"/api/v1/categories/by-slug/x", "/api/v1/nope", "/api/v1/categories/by-slug/y"} {
+		codes = append(codes, get(path, "192.0.2.1:1000", ""))
+	}
+	expect("public", codes, 404, 404, 429)
+
+	// Probes are never limited.
+	codes = nil
+	for i := 0; i < 5; i++ {
+		codes = append(codes, get("/api/v1/health/live", "192.0.2.1:1000", ""))
+	}
+	expect("probes", codes, 200, 200, 200, 200, 200)
+
+	// Other addresses have budgets of their own.
+	if got := get("/api/v1/admin/users", "192.0.2.2:1000", ""); got != http.StatusUnauthorized {
+		t.Errorf("admin from another address: status %d, want 401", got)
+	}
+}
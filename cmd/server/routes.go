@@ -0,0 +1,120 @@
+package main
+
+import (
+	"net/http"
+
+	"seattle-info-platform/internal/platform/auth"
+	"seattle-info-platform/internal/platform/cors"
+	"seattle-info-platform/internal/platform/health"
+	"seattle-info-platform/internal/platform/logging"
+	"seattle-info-platform/internal/platform/problem"
+	"seattle-info-platform/internal/platform/ratelimit"
+	"seattle-info-platform/internal/platform/requestid"
+	"seattle-info-platform/internal/platform/securityheaders"
+	"seattle-info-platform/pkg/config"
+)
+
+// exposedHeaders are the response headers the dashboard may read from
+// cross-origin responses.
+var exposedHeaders = []string{
+	requestid.Header,
+	"Location",
+	"Retry-After",
+	ratelimit.HeaderLimit,
+	ratelimit.HeaderRemaining,
+	ratelimit.HeaderReset,
+}
+
+// routes declares every HTTP route of the server. Method and path wildcards
+// are matched by http.ServeMux, which also answers unknown methods with 405
+// and an Allow header; problem.Mux turns its 404s and 405s into problems.
+// Handlers read IDs with r.PathValue.
+func (a *app) routes(cfg *config.Config, verifier *auth.Verifier) http.Handler {
+	mux := problem.NewMux()
+
+	// Each route group has its own budget per client; see
+	// config.RateLimitConfig.
+	limits := cfg.RateLimit
+	public := newLimiter(limits.PublicRate, limits.PublicBurst, limits.MaxClients).Middleware(a.publicKey)
+	adminAddress := newLimiter(limits.AdminIPRate, limits.AdminIPBurst, limits.MaxClients).Middleware(a.addressKey)
+	admin := newLimiter(limits.AdminRate, limits.AdminBurst, limits.MaxClients).Middleware(a.accountKey)
+
+	apiV1 := problem.NewMux()
+	// Probes. /health predates the split and is kept as an alias of
+	// readiness.
+	apiV1.HandleFunc("GET /health", a.health.Ready) // Path seen by handler: /health
+	apiV1.HandleFunc("GET /health/live", health.Live)
+	apiV1.HandleFunc("GET /health/ready", a.health.Ready)
+	apiV1.HandleFunc("GET /categories/by-slug/{slug}", a.categoryBySlugHandler)
+
+	// Every /admin/* route requires a verified Firebase ID token.
+	// The caller must also hold a role that auth.Policy grants admin access,
+	// and each route requires the permission it needs.
+	// Requests are rate limited by address before the token is checked, so
+	// guessing tokens is throttled too, and by account after.
+	adminAPI := problem.NewMux()
+	apiV1.Handle("/admin/", adminAddress(auth.Middleware(verifier)(auth.Authorize(a.lookupAccount)(admin(a.auditActor(logging.Route("/api/v1", adminAPI)))))))
+
+	// Admin User Management API Endpoints
+	adminAPI.Handle("GET /admin/users", auth.Require(auth.PermViewUsers, http.HandlerFunc(a.adminListUsersHandler)))
+	adminAPI.Handle("POST /admin/users/{id}/approve", auth.Require(auth.PermReviewUsers, http.HandlerFunc(a.adminApproveUserHandler)))
+	adminAPI.Handle("POST /admin/users/{id}/reject", auth.Require(auth.PermReviewUsers, http.HandlerFunc(a.adminRejectUserHandler)))
+	adminAPI.Handle("POST /admin/users/{id}/suspend", auth.Require(auth.PermSuspendUsers, http.HandlerFunc(a.adminSuspendUserHandler)))
+	adminAPI.Handle("POST /admin/users/{id}/reactivate", auth.Require(auth.PermSuspendUsers, http.HandlerFunc(a.adminReactivateUserHandler)))
+	adminAPI.Handle("POST /admin/users/{id}/deactivate", auth.Require(auth.PermDeactivateUsers, http.HandlerFunc(a.adminDeactivateUserHandler)))
+	adminAPI.Handle("PUT /admin/users/{id}/role", auth.Require(auth.PermChangeUserRoles, http.HandlerFunc(a.adminChangeUserRoleHandler)))
+
+	// Admin Listing Management API Endpoints
+	adminAPI.Handle("GET /admin/listings", auth.Require(auth.PermViewListings, http.HandlerFunc(a.adminListListingsHandler)))
+	adminAPI.Handle("PUT /admin/listings/{id}/status", auth.Require(auth.PermModerateListings, http.HandlerFunc(a.adminUpdateListingStatusHandler)))
+
+	// Admin Category Management API Endpoints
+	adminAPI.Handle("GET /admin/categories", auth.Require(auth.PermViewCategories, http.HandlerFunc(a.adminListCategoriesHandler)))
+	adminAPI.Handle("GET /admin/categories/tree", auth.Require(auth.PermViewCategories, http.HandlerFunc(a.adminCategoryTreeHandler)))
+	adminAPI.Handle("POST /admin/categories", auth.Require(auth.PermManageCategories, http.HandlerFunc(a.adminCreateCategoryHandler)))
+	adminAPI.Handle("PUT /admin/categories/{id}", auth.Require(auth.PermManageCategories, http.HandlerFunc(a.adminUpdateCategoryHandler)))
+	adminAPI.Handle("DELETE /admin/categories/{id}", auth.Require(auth.PermManageCategories, http.HandlerFunc(a.adminDeleteCategoryHandler)))
+
+	// Admin Audit Log API Endpoints
+	adminAPI.Handle("GET /admin/audit", auth.Require(auth.PermViewAudit, http.HandlerFunc(a.adminListAuditHandler)))
+	adminAPI.Handle("GET /admin/audit/verify", auth.Require(auth.PermViewAudit, http.HandlerFunc(a.adminVerifyAuditHandler)))
+
+	// Prefix /api/v1 to all routes in apiV1. Every public route, including
+	// unknown paths, is rate limited by address; see publicKey.
+	mux.Handle("/api/v1/", http.StripPrefix("/api/v1", public(logging.Route("/api/v1", apiV1))))
+
+	// Admin frontend static files
+	adminFS := http.FileServer(http.Dir(cfg.Server.StaticDir))
+	mux.Handle("GET /admin/", securityheaders.CSP(cfg.Security.AdminCSP)(http.StripPrefix("/admin/", adminFS))) // Serves index.html from /admin/
+
+	// Root path
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("Welcome to Seattle Info Platform API"))
+	})
+
+	// Preflight requests are answered by the CORS middleware, before they
+	// reach authentication or rate limits.
+	secure := securityheaders.Middleware(securityheaders.Options{
+		HSTSMaxAge: cfg.Security.HSTSMaxAge,
+		CSP:        securityheaders.APIPolicy,
+	})
+	crossOrigin := cors.Middleware(cors.Options{
+		AllowedOrigins:   cfg.CORS.AllowedOrigins,
+		AllowedMethods:   cfg.CORS.AllowedMethods,
+		AllowedHeaders:   cfg.CORS.AllowedHeaders,
+		ExposedHeaders:   exposedHeaders,
+		AllowCredentials: cfg.CORS.AllowCredentials,
+		MaxAge:           cfg.CORS.MaxAge,
+	})
+	return requestid.Middleware(logging.Middleware(a.httpMetrics.Middleware(secure(crossOrigin(logging.Route("", mux))))))
+}
+
+// metricsRoutes serves the Prometheus metrics. They are served on their
+// own listener, server.metrics_addr, rather than by routes: scrapes are
+// neither authenticated nor rate limited.
+func (a *app) metricsRoutes() http.Handler {
+	mux := problem.NewMux()
+	mux.Handle("GET /metrics", a.metrics.Handler())
+	return mux
+}
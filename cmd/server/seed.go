@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"seattle-info-platform/internal/category"
+	"seattle-info-platform/internal/listing"
+	"seattle-info-platform/internal/platform/database"
+	"seattle-info-platform/internal/user"
+)
+
+// seedDemoData fills an empty store with the demo records the admin
+// dashboard was originally built against. It does nothing if any user exists.
+func seedDemoData(ctx context.Context, store database.Store) error {
+	existing, err := store.Users().List(ctx, database.UserFilter{})
+	if err != nil {
+		return err
+	}
+	if len(existing) > 0 {
+		return nil
+	}
+
+	now := time.Now()
+	users := []user.User{
+		{ID: "user1", Email: "pending1@example.com", FirstName: "Pending", LastName: "UserOne", Role: user.RoleUser, Status: user.StatusPendingApproval, RegistrationDate: now.Add(-24 * time.Hour), CreatedAt: now.Add(-24 * time.Hour), UpdatedAt: now.Add(-24 * time.Hour)},
+		{ID: "user2", Email: "activeuser@example.com", FirstName: "Active", LastName: "UserTwo", Role: user.RoleUser, Status: user.StatusActive, RegistrationDate: now.Add(-48 * time.Hour), CreatedAt: now.Add(-48 * time.Hour), UpdatedAt: now.Add(-48 * time.Hour)},
+		{ID: "user3", Email: "pending2@example.com", FirstName: "Pending", LastName: "UserThree", Role: user.RoleUser, Status: user.StatusPendingApproval, RegistrationDate: now.Add(-72 * time.Hour), CreatedAt: now.Add(-72 * time.Hour), UpdatedAt: now.Add(-72 * time.Hour)},
+		{ID: "admin1", Email: "admin@example.com", FirstName: "Admin", LastName: "Super", Role: user.RoleAdmin, Status: user.StatusActive, RegistrationDate: now.Add(-96 * time.Hour), CreatedAt: now.Add(-96 * time.Hour), UpdatedAt: now.Add(-96 * time.Hour)},
+	}
+	categories := []category.Category{
+		{ID: "cat1", Name: "Electronics", Slug: "electronics", CreatedAt: now, UpdatedAt: now},
+		{ID: "cat2", Name: "Furniture", Slug: "furniture", CreatedAt: now, UpdatedAt: now},
+	}
+	listings := []listing.Listing{
+		{ID: "listing1", Title: "Pending Laptop", Description: "A great laptop, awaiting approval.", Status: listing.StatusPendingApproval, SubmitterID: "user1", CategoryID: "cat1", CreationDate: now.Add(-5 * time.Hour), LastUpdatedDate: now.Add(-5 * time.Hour), CreatedAt: now.Add(-5 * time.Hour), UpdatedAt: now.Add(-5 * time.Hour)},
+		{ID: "listing2", Title: "Active Chair", Description: "A comfortable office chair.", Status: listing.StatusActive, SubmitterID: "user2", CategoryID: "cat2", CreationDate: now.Add(-10 * time.Hour), LastUpdatedDate: now.Add(-10 * time.Hour), CreatedAt: now.Add(-10 * time.Hour), UpdatedAt: now.Add(-10 * time.Hour)},
+		{ID: "listing3", Title: "Another Pending Item", Description: "Something else to review.", Status: listing.StatusPendingApproval, SubmitterID: "user1", CategoryID: "cat1", CreationDate: now.Add(-2 * time.Hour), LastUpdatedDate: now.Add(-2 * time.Hour), CreatedAt: now.Add(-2 * time.Hour), UpdatedAt: now.Add(-2 * time.Hour)},
+	}
+
+	for i := range users {
+		if err := store.Users().Create(ctx, &users[i]); err != nil {
+			return fmt.Errorf("seed user %s: %w", users[i].ID, err)
+		}
+	}
+	for i := range categories {
+		if err := store.Categories().Create(ctx, &categories[i], nil); err != nil {
+			return fmt.Errorf("seed category %s: %w", categories[i].ID, err)
+		}
+	}
+	for i := range listings {
+		if err := store.Listings().Create(ctx, &listings[i]); err != nil {
+			return fmt.Errorf("seed listing %s: %w", listings[i].ID, err)
+		}
+	}
+	return nil
+}
+
+// bootstrapAdmin creates an active admin account for the Firebase UID uid
+// unless an account with that ID already exists. It is the only way to get
+// the first administrator into a database that was not seeded.
+func bootstrapAdmin(ctx context.Context, store database.Store, uid string) error {
+	_, err := store.Users().Get(ctx, uid)
+	if !errors.Is(err, database.ErrNotFound) {
+		return err
+	}
+	now := time.Now()
+	u := user.User{
+		ID:               uid,
+		Role:             user.RoleAdmin,
+		Status:           user.StatusActive,
+		AuthProvider:     "firebase",
+		RegistrationDate: now,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+	if err := store.Users().Create(ctx, &u); err != nil {
+		return err
+	}
+	slog.Warn("created bootstrap admin account", "user_id", uid)
+	return nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"seattle-info-platform/internal/platform/auth"
+	"seattle-info-platform/internal/platform/database"
+	"seattle-info-platform/internal/user"
+)
+
+func TestBootstrapAdmin(t *testing.T) {
+	ctx := context.Background()
+	store := database.NewMemoryStore()
+	a := newApp(store, nil)
+
+	if _, err := a.lookupAccount(ctx, &auth.Principal{UID: "firebase-uid"}); !errors.Is(err, auth.ErrNoAccount) {
+		t.Fatalf("lookupAccount before bootstrap: %v, want ErrNoAccount", err)
+	}
+	if err := bootstrapAdmin(ctx, store, "firebase-uid"); err != nil {
+		t.Fatal(err)
+	}
+	u, err := a.lookupAccount(ctx, &auth.Principal{UID: "firebase-uid"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Role != user.RoleAdmin || u.Status != user.StatusActive {
+		t.Errorf("bootstrap account is %s %q, want an active admin", u.Role, u.Status)
+	}
+
+	// An existing account, even a demoted one, is left alone. Another admin
+	// takes over first: the store keeps one active admin.
+	if err := store.Users().Create(ctx, &user.User{ID: "admin2", Role: user.RoleAdmin, Status: user.StatusActive}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Users().UpdateFunc(ctx, "firebase-uid", func(u *user.User) error {
+		u.Role = user.RoleUser
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := bootstrapAdmin(ctx, store, "firebase-uid"); err != nil {
+		t.Fatal(err)
+	}
+	if u, _ := store.Users().Get(ctx, "firebase-uid"); u.Role != user.RoleUser {
+		t.Errorf("second bootstrap changed the role to %s", u.Role)
+	}
+}
+
+func TestLookupAccountIgnoresEmail(t *testing.T) {
+	ctx := context.Background()
+	store := database.NewMemoryStore()
+	if err := seedDemoData(ctx, store); err != nil {
+		t.Fatal(err)
+	}
+	a := newApp(store, nil)
+
+	// A Firebase account that merely shares the demo admin's verified
+	// email must not take over that account.
+	_, err := a.lookupAccount(ctx, &auth.Principal{UID: "other", Email: "admin@example.com", EmailVerified: true})
+	if !errors.Is(err, auth.ErrNoAccount) {
+		t.Errorf("lookupAccount by email: %v, want ErrNoAccount", err)
+	}
+}
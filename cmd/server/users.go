@@ -0,0 +1,244 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"seattle-info-platform/internal/platform/auth"
+	"seattle-info-platform/internal/platform/database"
+	"seattle-info-platform/internal/platform/problem"
+	"seattle-info-platform/internal/platform/validate"
+	"seattle-info-platform/internal/user"
+)
+
+// parseUserFilter reads the user search parameters: status, role, q,
+// registered_after, registered_before, auth_provider and is_email_verified.
+// Dates are RFC 3339 timestamps or plain YYYY-MM-DD dates (midnight UTC).
+func parseUserFilter(query url.Values) (database.UserFilter, error) {
+	filter := database.UserFilter{
+		Status:       user.UserStatus(query.Get("status")),
+		Role:         user.UserRole(query.Get("role")),
+		Query:        query.Get("q"),
+		AuthProvider: query.Get("auth_provider"),
+	}
+	if filter.Status != "" && !filter.Status.IsValid() {
+		return filter, fmt.Errorf("invalid status %q", filter.Status)
+	}
+	if filter.Role != "" && !filter.Role.IsValid() {
+		return filter, fmt.Errorf("invalid role %q", filter.Role)
+	}
+	for param, dst := range map[string]*time.Time{
+		"registered_after":  &filter.RegisteredAfter,
+		"registered_before": &filter.RegisteredBefore,
+	} {
+		var err error
+		if *dst, err = parseTimeParam(query, param); err != nil {
+			return filter, err
+		}
+	}
+	if v := query.Get("is_email_verified"); v != "" {
+		verified, err := strconv.ParseBool(v)
+		if err != nil {
+			return filter, fmt.Errorf("is_email_verified must be true or false")
+		}
+		filter.EmailVerified = &verified
+	}
+	return filter, nil
+}
+
+// parseTimeParam reads an RFC 3339 timestamp or a plain YYYY-MM-DD date
+// (midnight UTC). A missing parameter yields the zero time.
+func parseTimeParam(query url.Values, param string) (time.Time, error) {
+	v := query.Get(param)
+	if v == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		if t, err = time.Parse(time.DateOnly, v); err != nil {
+			return t, fmt.Errorf("%s must be a date (YYYY-MM-DD) or an RFC 3339 timestamp", param)
+		}
+	}
+	return t, nil
+}
+
+func (a *app) adminListUsersHandler(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseUserFilter(r.URL.Query())
+	if err != nil {
+		problem.Error(w, r, http.StatusBadRequest, problem.CodeInvalidQuery, "Invalid filter: "+err.Error())
+		return
+	}
+	page, ok := parsePage(w, r, userPages)
+	if !ok {
+		return
+	}
+	resultUsers, err := a.store.Users().List(r.Context(), filter)
+	if err != nil {
+		internalError(w, r, "Failed to list users", err)
+		return
+	}
+
+	writePage(w, r, userPages, page, resultUsers)
+}
+
+// UserStatusChangeRequest is the body of the reject, suspend and deactivate
+// endpoints.
+type UserStatusChangeRequest struct {
+	Reason string `json:"reason" validate:"max=500"`
+}
+
+// decodeStatusChange reads an optional UserStatusChangeRequest body. An empty
+// body yields an empty reason; the user service decides whether that is
+// acceptable.
+func decodeStatusChange(w http.ResponseWriter, r *http.Request) (UserStatusChangeRequest, bool) {
+	var req UserStatusChangeRequest
+	return req, checkBody(w, r, validate.DecodeOptionalJSON(w, r, &req), nil)
+}
+
+// userConflictCodes names, per action, the problem code for a user whose
+// status does not allow it.
+var userConflictCodes = map[string]string{
+	"approved":    codeUserNotPending,
+	"rejected":    codeUserNotPending,
+	"suspended":   codeUserNotActive,
+	"reactivated": codeUserNotSuspended,
+	"deactivated": codeUserInactive,
+}
+
+// writeUserResult writes the outcome of a user service call: the updated user
+// or an error mapped to a problem response.
+func writeUserResult(w http.ResponseWriter, r *http.Request, userId, action string, u *user.User, err error) {
+	var transitionErr *user.TransitionError
+	switch {
+	case err == nil:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(u)
+		slog.InfoContext(r.Context(), "user updated", "user_id", userId, "action", action)
+	case errors.Is(err, database.ErrNotFound):
+		problem.Error(w, r, http.StatusNotFound, codeUserNotFound, "User not found")
+	case errors.As(err, &transitionErr):
+		slog.InfoContext(r.Context(), "user transition refused", "user_id", userId, "action", action, "err", err)
+		code, ok := userConflictCodes[action]
+		if !ok {
+			code = codeInvalidUserTransition
+		}
+		allowed := transitionErr.Allowed()
+		detail := fmt.Sprintf("User status %q cannot change to %q.", transitionErr.From, transitionErr.To)
+		if transitionErr.Required != "" {
+			detail = fmt.Sprintf("User status is %q; only %q users can be %s.", transitionErr.From, transitionErr.Required, action)
+		}
+		problem.Write(w, r, problem.New(http.StatusConflict, code, detail).
+			With("current_status", transitionErr.From).
+			With("allowed_statuses", allowed))
+	case errors.Is(err, user.ErrReasonRequired):
+		invalidField(w, r, codeReasonRequired, "reason", "required", "A reason is required")
+	case errors.Is(err, user.ErrSelfChange):
+		problem.Error(w, r, http.StatusForbidden, codeSelfChange, "You cannot change your own account")
+	case errors.Is(err, user.ErrOutranked):
+		problem.Error(w, r, http.StatusForbidden, codeTargetRoleForbidden, "Only admins can change moderator and admin accounts")
+	case errors.Is(err, user.ErrLastAdmin):
+		problem.Error(w, r, http.StatusConflict, codeLastAdmin, "The last active admin cannot be removed")
+	case errors.Is(err, user.ErrInvalidRole):
+		invalidField(w, r, codeInvalidRole, "role", "invalid", "Invalid role specified. Must be 'user', 'moderator' or 'admin'.")
+	default:
+		internalError(w, r, "Failed to update user "+userId, err)
+	}
+}
+
+// caller returns the account making the request, stored by auth.Authorize.
+// Without one the request is refused.
+func caller(w http.ResponseWriter, r *http.Request) (*user.User, bool) {
+	account, ok := auth.AccountFromContext(r.Context())
+	if !ok {
+		problem.Error(w, r, http.StatusForbidden, problem.CodeForbidden, "You do not have permission to perform this action")
+	}
+	return account, ok
+}
+
+func (a *app) adminApproveUserHandler(w http.ResponseWriter, r *http.Request) {
+	userId := r.PathValue("id")
+	by, ok := caller(w, r)
+	if !ok {
+		return
+	}
+	u, err := a.users.Approve(r.Context(), by, userId)
+	writeUserResult(w, r, userId, "approved", u, err)
+}
+
+func (a *app) adminRejectUserHandler(w http.ResponseWriter, r *http.Request) {
+	userId := r.PathValue("id")
+	by, ok := caller(w, r)
+	if !ok {
+		return
+	}
+	req, ok := decodeStatusChange(w, r)
+	if !ok {
+		return
+	}
+	u, err := a.users.Reject(r.Context(), by, userId, req.Reason)
+	writeUserResult(w, r, userId, "rejected", u, err)
+}
+
+func (a *app) adminSuspendUserHandler(w http.ResponseWriter, r *http.Request) {
+	userId := r.PathValue("id")
+	by, ok := caller(w, r)
+	if !ok {
+		return
+	}
+	req, ok := decodeStatusChange(w, r)
+	if !ok {
+		return
+	}
+	u, err := a.users.Suspend(r.Context(), by, userId, req.Reason)
+	writeUserResult(w, r, userId, "suspended", u, err)
+}
+
+func (a *app) adminReactivateUserHandler(w http.ResponseWriter, r *http.Request) {
+	userId := r.PathValue("id")
+	by, ok := caller(w, r)
+	if !ok {
+		return
+	}
+	u, err := a.users.Reactivate(r.Context(), by, userId)
+	writeUserResult(w, r, userId, "reactivated", u, err)
+}
+
+func (a *app) adminDeactivateUserHandler(w http.ResponseWriter, r *http.Request) {
+	userId := r.PathValue("id")
+	by, ok := caller(w, r)
+	if !ok {
+		return
+	}
+	req, ok := decodeStatusChange(w, r)
+	if !ok {
+		return
+	}
+	u, err := a.users.Deactivate(r.Context(), by, userId, req.Reason)
+	writeUserResult(w, r, userId, "deactivated", u, err)
+}
+
+// UpdateRoleRequest is the body of the change role endpoint.
+type UpdateRoleRequest struct {
+	Role user.UserRole `json:"role" validate:"required"`
+}
+
+func (a *app) adminChangeUserRoleHandler(w http.ResponseWriter, r *http.Request) {
+	userId := r.PathValue("id")
+	by, ok := caller(w, r)
+	if !ok {
+		return
+	}
+	var req UpdateRoleRequest
+	if !decodeBody(w, r, &req, roleCodes) {
+		return
+	}
+
+	u, err := a.users.ChangeRole(r.Context(), by, userId, req.Role)
+	writeUserResult(w, r, userId, "changed role to "+string(req.Role), u, err)
+}
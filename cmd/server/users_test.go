@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"seattle-info-platform/internal/audit"
+	"seattle-info-platform/internal/platform/auth"
+	"seattle-info-platform/internal/platform/database"
+	"seattle-info-platform/internal/platform/problem"
+	"seattle-info-platform/internal/user"
+)
+
+func TestParseUserFilter(t *testing.T) {
+	for _, tc := range []struct {
+		query string
+		check func(database.UserFilter) bool
+		ok    bool
+	}{
+		{"", func(f database.UserFilter) bool { return f == (database.UserFilter{}) }, true},
+		{"status=Active&role=moderator&q=ann&auth_provider=firebase", func(f database.UserFilter) bool {
+			return f.Status == user.StatusActive && f.Role == user.RoleModerator && f.Query == "ann" && f.AuthProvider == "firebase"
+		}, true},
+		{"status=Pending+Approval", func(f database.UserFilter) bool { return f.Status == user.StatusPendingApproval }, true},
+		{"registered_after=2024-03-01&registered_before=2024-04-01T12:00:00Z", func(f database.UserFilter) bool {
+			return f.RegisteredAfter.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)) &&
+				f.RegisteredBefore.Equal(time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC))
+		}, true},
+		{"is_email_verified=false", func(f database.UserFilter) bool { return f.EmailVerified != nil && !*f.EmailVerified }, true},
+
+		{"status=active", nil, false},
+		{"status=bogus", nil, false},
+		{"role=root", nil, false},
+		{"registered_after=yesterday", nil, false},
+		{"is_email_verified=maybe", nil, false},
+	} {
+		query, err := url.ParseQuery(tc.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f, err := parseUserFilter(query)
+		if (err == nil) != tc.ok {
+			t.Errorf("parseUserFilter(%q) error = %v, want ok %v", tc.query, err, tc.ok)
+			continue
+		}
+		if tc.ok && !tc.check(f) {
+			t.Errorf("parseUserFilter(%q) = %+v", tc.query, f)
+		}
+	}
+}
+
+func TestListStatusFilterRejected(t *testing.T) {
+	store := database.NewMemoryStore()
+	if err := seedDemoData(context.Background(), store); err != nil {
+		t.Fatal(err)
+	}
+	auditLog, err := audit.Open("", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := newApp(store, auditLog)
+
+	for _, tc := range []struct {
+		h      http.HandlerFunc
+		target string
+		status int
+	}{
+		{a.adminListUsersHandler, "/admin/users?status=bogus", http.StatusBadRequest},
+		{a.adminListUsersHandler, "/admin/users?role=bogus", http.StatusBadRequest},
+		{a.adminListUsersHandler, "/admin/users?status=Active", http.StatusOK},
+		{a.adminListListingsHandler, "/admin/listings?status=bogus", http.StatusBadRequest},
+		{a.adminListListingsHandler, "/admin/listings?status=bogus&q=chair", http.StatusBadRequest},
+		{a.adminListListingsHandler, "/admin/listings?status=active", http.StatusOK},
+	} {
+		w := call(tc.h, http.MethodGet, tc.target, "", "")
+		if w.Code != tc.status {
+			t.Errorf("GET %s: status %d, want %d: %s", tc.target, w.Code, tc.status, w.Body)
+			continue
+		}
+		if tc.status != http.StatusBadRequest {
+			continue
+		}
+		var p problem.Problem
+		if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil || p.Code != problem.CodeInvalidQuery {
+			t.Errorf("GET %s: code %q, %v; want %s", tc.target, p.Code, err, problem.CodeInvalidQuery)
+		}
+	}
+}
+
+func TestUserActionsRequireStatus(t *testing.T) {
+	ctx := context.Background()
+	store := database.NewMemoryStore()
+	if err := seedDemoData(ctx, store); err != nil {
+		t.Fatal(err)
+	}
+	auditLog, err := audit.Open("", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := newApp(store, auditLog)
+	if _, err := a.users.Suspend(audit.NewContext(ctx, audit.Actor{ID: "admin1"}), &user.User{ID: "admin1", Role: user.RoleAdmin}, "user2", "spam"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		h      http.HandlerFunc
+		id     string
+		code   string
+		status user.UserStatus
+	}{
+		// Suspended users may become active, but only by reactivation.
+		{"approve suspended", a.adminApproveUserHandler, "user2", codeUserNotPending, user.StatusSuspended},
+		// Pending users may become active, but only by approval.
+		{"reactivate pending", a.adminReactivateUserHandler, "user1", codeUserNotSuspended, user.StatusPendingApproval},
+	} {
+		w := call(tc.h, http.MethodPost, "/", tc.id, "")
+		var p problem.Problem
+		json.Unmarshal(w.Body.Bytes(), &p)
+		if w.Code != http.StatusConflict || p.Code != tc.code {
+			t.Errorf("%s: %d %q, want 409 %s: %s", tc.name, w.Code, p.Code, tc.code, w.Body)
+		}
+		if u, _ := store.Users().Get(ctx, tc.id); u.Status != tc.status {
+			t.Errorf("%s: status = %q, want %q unchanged", tc.name, u.Status, tc.status)
+		}
+	}
+	for _, action := range []string{"user.approve", "user.reactivate"} {
+		if entries := auditLog.Query(audit.Filter{Action: action}); len(entries) != 0 {
+			t.Errorf("refused %s was recorded: %+v", action, entries)
+		}
+	}
+}
+
+// callAs is call on behalf of account instead of the seeded admin.
+func callAs(h http.HandlerFunc, account *user.User, method, id, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, "/", strings.NewReader(body))
+	r = r.WithContext(auth.NewAccountContext(r.Context(), account))
+	r = r.WithContext(audit.NewContext(r.Context(), audit.Actor{ID: account.ID, Role: string(account.Role)}))
+	r.SetPathValue("id", id)
+	w := httptest.NewRecorder()
+	h(w, r)
+	return w
+}
+
+func TestUserChangeGuards(t *testing.T) {
+	ctx := context.Background()
+	store := database.NewMemoryStore()
+	if err := seedDemoData(ctx, store); err != nil {
+		t.Fatal(err)
+	}
+	for _, u := range []user.User{
+		{ID: "mod1", Role: user.RoleModerator, Status: user.StatusActive},
+		{ID: "mod2", Role: user.RoleModerator, Status: user.StatusActive},
+		{ID: "admin2", Role: user.RoleAdmin, Status: user.StatusActive},
+	} {
+		if err := store.Users().Create(ctx, &u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	auditLog, err := audit.Open("", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := newApp(store, auditLog)
+	admin1, _ := store.Users().Get(ctx, "admin1")
+	mod1, _ := store.Users().Get(ctx, "mod1")
+	// ghost is an admin whose own account is gone, so that admin1 and
+	// admin2 can become the last active admin without being the caller.
+	ghost := &user.User{ID: "ghost", Role: user.RoleAdmin, Status: user.StatusActive}
+
+	for _, tc := range []struct {
+		name   string
+		by     *user.User
+		h      http.HandlerFunc
+		method string
+		id     string
+		body   string
+		status int
+		code   string
+	}{
+		{"moderator suspends admin", mod1, a.adminSuspendUserHandler, "POST", "admin1", `{"reason":"x"}`, http.StatusForbidden, codeTargetRoleForbidden},
+		{"moderator deactivates moderator", mod1, a.adminDeactivateUserHandler, "POST", "mod2", "", http.StatusForbidden, codeTargetRoleForbidden},
+		{"moderator suspends user", mod1, a.adminSuspendUserHandler, "POST", "user2", `{"reason":"spam"}`, http.StatusOK, ""},
+		{"moderator suspends self", mod1, a.adminSuspendUserHandler, "POST", "mod1", `{"reason":"x"}`, http.StatusForbidden, codeSelfChange},
+		{"admin suspends self", admin1, a.adminSuspendUserHandler, "POST", "admin1", `{"reason":"x"}`, http.StatusForbidden, codeSelfChange},
+		{"admin demotes self", admin1, a.adminChangeUserRoleHandler, "PUT", "admin1", `{"role":"user"}`, http.StatusForbidden, codeSelfChange},
+		{"admin demotes admin", admin1, a.adminChangeUserRoleHandler, "PUT", "admin2", `{"role":"moderator"}`, http.StatusOK, ""},
+		{"admin suspends moderator", admin1, a.adminSuspendUserHandler, "POST", "mod2", `{"reason":"x"}`, http.StatusOK, ""},
+		{"last admin demoted", ghost, a.adminChangeUserRoleHandler, "PUT", "admin1", `{"role":"user"}`, http.StatusConflict, codeLastAdmin},
+		{"last admin deactivated", ghost, a.adminDeactivateUserHandler, "POST", "admin1", "", http.StatusConflict, codeLastAdmin},
+	} {
+		w := callAs(tc.h, tc.by, tc.method, tc.id, tc.body)
+		var p problem.Problem
+		json.Unmarshal(w.Body.Bytes(), &p)
+		if w.Code != tc.status || p.Code != tc.code {
+			t.Errorf("%s: %d %q, want %d %q: %s", tc.name, w.Code, p.Code, tc.status, tc.code, w.Body)
+		}
+	}
+
+	if u, _ := store.Users().Get(ctx, "admin1"); u.Role != user.RoleAdmin || u.Status != user.StatusActive {
+		t.Errorf("admin1 = %s %q, want an active admin", u.Role, u.Status)
+	}
+}
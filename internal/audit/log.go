@@ -0,0 +1,233 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Filter narrows the result of Log.Query. Zero-valued fields are ignored.
+type Filter struct {
+	// Target matches the target ID, or "<type>:<id>" to match both.
+	Target string
+	// Actor matches the actor's ID or, ignoring case, email.
+	Actor  string
+	Action string
+	// Since keeps entries recorded at or after it.
+	Since time.Time
+}
+
+// Matches reports whether e satisfies every condition of f.
+func (f Filter) Matches(e *Entry) bool {
+	if f.Target != "" {
+		typ, id, ok := strings.Cut(f.Target, ":")
+		if !ok {
+			typ, id = "", f.Target
+		}
+		if e.TargetID != id || (typ != "" && e.TargetType != typ) {
+			return false
+		}
+	}
+	switch {
+	case f.Actor != "" && e.Actor.ID != f.Actor && !strings.EqualFold(e.Actor.Email, f.Actor),
+		f.Action != "" && e.Action != f.Action,
+		!f.Since.IsZero() && e.Time.Before(f.Since):
+		return false
+	}
+	return true
+}
+
+// Log is an append-only audit log, kept in memory and, when opened with a
+// path, in a file that every Record appends to and syncs. It is safe for
+// concurrent use.
+type Log struct {
+	key  []byte
+	path string
+	now  func() time.Time
+
+	mu      sync.Mutex
+	file    *os.File // nil for a memory-only log
+	size    int64    // bytes of complete entries in file
+	entries []Entry
+	closed  bool
+}
+
+// Open opens the log file at path, creating it if needed, and verifies its
+// chain; a broken chain fails with ErrTampered. A last line cut short by a
+// crash during Record is removed, since Record never reported it as
+// written. An empty path gives a log that lives only in memory.
+func Open(path string, key []byte) (*Log, error) {
+	l := &Log{key: key, path: path, now: time.Now}
+	if path == "" {
+		return l, nil
+	}
+	entries, size, err := readEntries(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := verify(entries, key); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("audit: create directory for %s: %w", path, err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("audit: open %s: %w", path, err)
+	}
+	if info, err := f.Stat(); err == nil && info.Size() > size {
+		slog.Warn("removing incomplete last audit log entry", "path", path, "bytes", info.Size()-size)
+		if err := f.Truncate(size); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("audit: repair %s: %w", path, err)
+		}
+	}
+	l.file, l.size, l.entries = f, size, entries
+	return l, nil
+}
+
+// readEntries decodes the log file at path. size is the length of the
+// complete lines; bytes after the last newline are a torn write and are not
+// decoded. Any complete line that does not decode fails with ErrTampered.
+func readEntries(path string) (entries []Entry, size int64, err error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("audit: read %s: %w", path, err)
+	}
+	size = int64(bytes.LastIndexByte(data, '\n') + 1)
+	for line, rest := 1, data[:size]; len(rest) > 0; line++ {
+		var text []byte
+		text, rest, _ = bytes.Cut(rest, []byte{'\n'})
+		var e Entry
+		if err := json.Unmarshal(text, &e); err != nil {
+			return nil, 0, fmt.Errorf("%w: %s line %d: %v", ErrTampered, path, line, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, size, nil
+}
+
+// Record appends an entry for e, performed by the actor in ctx. The entry is
+// on disk when Record returns. Once the log is closed, Record fails with
+// ErrClosed rather than keep an entry that would never be written.
+func (l *Log) Record(ctx context.Context, e Event) error {
+	changes, err := Diff(e.Before, e.After)
+	if err != nil {
+		return err
+	}
+	actor, ok := FromContext(ctx)
+	if !ok {
+		actor = system
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return ErrClosed
+	}
+	entry := Entry{
+		Seq:        int64(len(l.entries) + 1),
+		Time:       l.now().UTC(),
+		Actor:      actor,
+		Action:     e.Action,
+		TargetType: e.TargetType,
+		TargetID:   e.TargetID,
+		Reason:     e.Reason,
+		Changes:    changes,
+		Details:    e.Details,
+	}
+	if n := len(l.entries); n > 0 {
+		entry.PrevHash = l.entries[n-1].Hash
+	}
+	if entry.Hash, err = entry.sum(l.key); err != nil {
+		return fmt.Errorf("audit: encode entry: %w", err)
+	}
+
+	if l.file != nil {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("audit: encode entry: %w", err)
+		}
+		line = append(line, '\n')
+		if _, err := l.file.Write(line); err != nil {
+			return l.discard(fmt.Errorf("audit: write %s: %w", l.path, err))
+		}
+		if err := l.file.Sync(); err != nil {
+			return l.discard(fmt.Errorf("audit: sync %s: %w", l.path, err))
+		}
+		l.size += int64(len(line))
+	}
+	l.entries = append(l.entries, entry)
+	return nil
+}
+
+// discard cuts the file back to the entries recorded before a failed write,
+// so the next entry does not follow a partial line, and returns err.
+func (l *Log) discard(err error) error {
+	if terr := l.file.Truncate(l.size); terr != nil {
+		return errors.Join(err, fmt.Errorf("audit: truncate %s: %w", l.path, terr))
+	}
+	return err
+}
+
+// Query returns the entries matching f, oldest first.
+func (l *Log) Query(f Filter) []Entry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var out []Entry
+	for i := range l.entries {
+		if f.Matches(&l.entries[i]) {
+			out = append(out, l.entries[i])
+		}
+	}
+	return out
+}
+
+// Verify rereads the log file, or checks the in-memory entries of a
+// memory-only log, and reports how many entries form a valid chain. The
+// error wraps ErrTampered if the chain is broken.
+func (l *Log) Verify() (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entries := l.entries
+	if l.file != nil {
+		var err error
+		if entries, _, err = readEntries(l.path); err != nil {
+			return 0, err
+		}
+		// The file must also still hold everything this process has seen,
+		// unchanged; a chain rebuilt with the key would otherwise pass.
+		if n := len(l.entries); n > 0 && (len(entries) < n || entries[n-1].Hash != l.entries[n-1].Hash) {
+			return 0, fmt.Errorf("%w: entries recorded since startup are missing or changed", ErrTampered)
+		}
+	}
+	if err := verify(entries, l.key); err != nil {
+		return 0, err
+	}
+	return len(entries), nil
+}
+
+// Close closes the log file. Entries recorded so far can still be queried.
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.closed = true
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
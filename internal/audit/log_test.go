@@ -0,0 +1,113 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRecordAfterClose(t *testing.T) {
+	for name, path := range map[string]string{
+		"file":   filepath.Join(t.TempDir(), "audit.jsonl"),
+		"memory": "",
+	} {
+		t.Run(name, func(t *testing.T) {
+			l, err := Open(path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx := context.Background()
+			if err := l.Record(ctx, Event{Action: "user.approve", TargetType: "user", TargetID: "u1"}); err != nil {
+				t.Fatal(err)
+			}
+			if err := l.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if err := l.Record(ctx, Event{Action: "user.reject", TargetType: "user", TargetID: "u1"}); !errors.Is(err, ErrClosed) {
+				t.Errorf("Record after Close = %v, want ErrClosed", err)
+			}
+			if n := len(l.Query(Filter{})); n != 1 {
+				t.Errorf("Query after Close returned %d entries, want 1", n)
+			}
+
+			if path == "" {
+				return
+			}
+			// Nothing was recorded that the file does not hold.
+			reopened, err := Open(path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer reopened.Close()
+			if n, err := reopened.Verify(); err != nil || n != 1 {
+				t.Errorf("Verify after reopening = %d, %v; want 1 entry", n, err)
+			}
+		})
+	}
+}
+
+func TestOpenRepairsTornTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	l, err := Open(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	for _, id := range []string{"u1", "u2"} {
+		if err := l.Record(ctx, Event{Action: "user.approve", TargetType: "user", TargetID: id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	l.Close()
+
+	// A crash during Record leaves part of a line behind.
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"seq":3,"time":"20`)
+	f.Close()
+
+	l, err = Open(path, nil)
+	if err != nil {
+		t.Fatalf("Open with a torn last line: %v", err)
+	}
+	if err := l.Record(ctx, Event{Action: "user.approve", TargetType: "user", TargetID: "u3"}); err != nil {
+		t.Fatal(err)
+	}
+	l.Close()
+
+	l, err = Open(path, nil)
+	if err != nil {
+		t.Fatalf("Open after repair: %v", err)
+	}
+	defer l.Close()
+	if n, err := l.Verify(); err != nil || n != 3 {
+		t.Errorf("Verify = %d, %v; want 3 entries", n, err)
+	}
+}
+
+func TestOpenRejectsCorruptLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	l, err := Open(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Record(context.Background(), Event{Action: "user.approve", TargetType: "user", TargetID: "u1"}); err != nil {
+		t.Fatal(err)
+	}
+	l.Close()
+
+	// A complete line that does not decode is not a torn write.
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("{not json}\n")
+	f.Close()
+	if _, err := Open(path, nil); !errors.Is(err, ErrTampered) {
+		t.Errorf("Open = %v, want ErrTampered", err)
+	}
+}
@@ -1,14 +1,132 @@
 package category
 
-// This file can be used for category service logic in the future.
-// For now, it just ensures the package is valid.
+import (
+	"context"
+	"errors"
+	"time"
+
+	"seattle-info-platform/internal/audit"
+)
+
+// slugRetries bounds how often Service retries a write that lost a race for
+// a slug.
+const slugRetries = 3
+
+// Store is the persistence the Service needs. database.CategoryRepository
+// satisfies it.
+type Store interface {
+	List(ctx context.Context) ([]Category, error)
+	Get(ctx context.Context, id string) (*Category, error)
+	// Create stores c, calling commit in the same atomic write.
+	Create(ctx context.Context, c *Category, commit func() error) error
+	// UpdateFunc atomically loads the category, applies fn and stores the result.
+	UpdateFunc(ctx context.Context, id string, fn func(*Category) error) (*Category, error)
+	// Delete removes the category, moving its listings to reassignTo, and
+	// calls commit in the same atomic write.
+	Delete(ctx context.Context, id, reassignTo string, commit func(reassigned int) error) (reassigned int, err error)
+}
+
+// Service creates, renames and deletes categories, giving each a unique
+// slug, and records every change in the audit log. Each change is recorded in
+// the same atomic write that stores it, so a change whose record fails is
+// not stored.
+type Service struct {
+	store Store
+	audit audit.Recorder
+	now   func() time.Time
+}
+
+// NewService returns a service that persists changes in store and records
+// them with rec.
+func NewService(store Store, rec audit.Recorder) *Service {
+	return &Service{store: store, audit: rec, now: time.Now}
+}
+
+// Create stores c with a slug derived from c.Name.
+func (s *Service) Create(ctx context.Context, c *Category) error {
+	return s.retry(ctx, func(taken func(string) bool) error {
+		c.Slug, c.OldSlugs = "", nil
+		c.SetName(c.Name, taken)
+		c.CreatedAt = s.now()
+		c.UpdatedAt = c.CreatedAt
+		return s.store.Create(ctx, c, func() error {
+			return s.record(ctx, audit.Event{Action: "category.create", TargetID: c.ID, After: c})
+		})
+	})
+}
+
+// Update applies fn to the category and stores the result. If fn changes the
+// name, the slug is regenerated and the old one kept as a redirect.
+func (s *Service) Update(ctx context.Context, id string, fn func(*Category) error) (updated *Category, err error) {
+	err = s.retry(ctx, func(taken func(string) bool) error {
+		updated, err = s.store.UpdateFunc(ctx, id, func(c *Category) error {
+			before := *c
+			oldName := c.Name
+			if err := fn(c); err != nil {
+				return err
+			}
+			if c.Name != oldName {
+				c.SetName(c.Name, func(slug string) bool {
+					return !c.hasSlug(slug) && taken(slug)
+				})
+			}
+			c.UpdatedAt = s.now()
+			return s.record(ctx, audit.Event{Action: "category.update", TargetID: id, Before: &before, After: c})
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// Delete removes the category, moving its listings to reassignTo, and
+// returns how many were moved. Errors from the store are returned unchanged.
+func (s *Service) Delete(ctx context.Context, id, reassignTo string) (int, error) {
+	before, err := s.store.Get(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	return s.store.Delete(ctx, id, reassignTo, func(moved int) error {
+		return s.record(ctx, audit.Event{Action: "category.delete", TargetID: id, Before: before,
+			Details: map[string]any{"reassigned_to": reassignTo, "reassigned_listings": moved}})
+	})
+}
+
+func (s *Service) record(ctx context.Context, e audit.Event) error {
+	e.TargetType = audit.TargetCategory
+	return s.audit.Record(ctx, e)
+}
+
+// retry calls write with a snapshot of the slugs in use, trying again if a
+// concurrent write claimed the chosen slug first.
+func (s *Service) retry(ctx context.Context, write func(taken func(string) bool) error) error {
+	var err error
+	for range slugRetries {
+		var all []Category
+		if all, err = s.store.List(ctx); err != nil {
+			return err
+		}
+		inUse := make(map[string]string) // slug → category ID
+		for _, c := range all {
+			for _, slug := range c.slugs() {
+				inUse[slug] = c.ID
+			}
+		}
+		if err = write(func(slug string) bool { _, ok := inUse[slug]; return ok }); !errors.Is(err, ErrDuplicateSlug) {
+			return err
+		}
+	}
+	return err
+}
 
 func GetMockCategory(id string) *Category {
 	// This is a mock function. In a real application, you would fetch this from a database.
 	return &Category{
-		ID: id,
-		Name: "Mock Category " + id,
-		Slug: "mock-category-" + id,
+		ID:          id,
+		Name:        "Mock Category " + id,
+		Slug:        "mock-category-" + id,
 		Description: "This is a mock category.",
 	}
 }
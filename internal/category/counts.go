@@ -0,0 +1,44 @@
+package category
+
+import "seattle-info-platform/internal/listing"
+
+// ListingCounts reports how many listings a category holds in each status.
+type ListingCounts struct {
+	// Own counts listings filed directly under the category.
+	Own map[listing.ListingStatus]int `json:"own"`
+	// Total adds the listings of every subcategory to Own.
+	Total map[listing.ListingStatus]int `json:"total"`
+}
+
+// RollUp turns per-category counts into ListingCounts for every category in
+// categories, adding each category's own counts to all of its ancestors.
+// Every category gets an entry, with empty maps if it has no listings.
+func RollUp(categories []Category, own map[string]map[listing.ListingStatus]int) map[string]ListingCounts {
+	parent := make(map[string]string, len(categories))
+	out := make(map[string]ListingCounts, len(categories))
+	for _, c := range categories {
+		parent[c.ID] = c.ParentCategoryID
+		counts := ListingCounts{
+			Own:   make(map[listing.ListingStatus]int),
+			Total: make(map[listing.ListingStatus]int),
+		}
+		for status, n := range own[c.ID] {
+			counts.Own[status] = n
+		}
+		out[c.ID] = counts
+	}
+	for _, c := range categories {
+		// The depth bound guards against a corrupt hierarchy; CheckHierarchy
+		// keeps real data well within it.
+		for id, depth := c.ID, 0; id != "" && depth <= MaxDepth; id, depth = parent[id], depth+1 {
+			ancestor, ok := out[id]
+			if !ok {
+				break
+			}
+			for status, n := range own[c.ID] {
+				ancestor.Total[status] += n
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,65 @@
+package category
+
+import (
+	"reflect"
+	"testing"
+
+	"seattle-info-platform/internal/listing"
+)
+
+func TestRollUp(t *testing.T) {
+	const (
+		active  = listing.StatusActive
+		pending = listing.StatusPendingApproval
+	)
+	categories := []Category{
+		{ID: "home"},
+		{ID: "furniture", ParentCategoryID: "home"},
+		{ID: "chairs", ParentCategoryID: "furniture"},
+		{ID: "jobs"},
+	}
+	own := map[string]map[listing.ListingStatus]int{
+		"home":      {active: 1},
+		"furniture": {active: 2, pending: 1},
+		"chairs":    {pending: 4},
+		// Counts of a category that no longer exists are ignored.
+		"gone": {active: 7},
+	}
+	want := map[string]ListingCounts{
+		"home": {
+			Own:   map[listing.ListingStatus]int{active: 1},
+			Total: map[listing.ListingStatus]int{active: 3, pending: 5},
+		},
+		"furniture": {
+			Own:   map[listing.ListingStatus]int{active: 2, pending: 1},
+			Total: map[listing.ListingStatus]int{active: 2, pending: 5},
+		},
+		"chairs": {
+			Own:   map[listing.ListingStatus]int{pending: 4},
+			Total: map[listing.ListingStatus]int{pending: 4},
+		},
+		"jobs": {
+			Own:   map[listing.ListingStatus]int{},
+			Total: map[listing.ListingStatus]int{},
+		},
+	}
+	got := RollUp(categories, own)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("RollUp = %v, want %v", got, want)
+	}
+
+	// The result does not alias the input.
+	got["home"].Own[active] = 100
+	if own["home"][active] != 1 {
+		t.Error("RollUp shares maps with its input")
+	}
+}
+
+func TestRollUpCycle(t *testing.T) {
+	// A corrupt hierarchy must not loop forever.
+	got := RollUp([]Category{{ID: "a", ParentCategoryID: "b"}, {ID: "b", ParentCategoryID: "a"}},
+		map[string]map[listing.ListingStatus]int{"a": {listing.StatusActive: 1}})
+	if got["a"].Own[listing.StatusActive] != 1 {
+		t.Errorf("RollUp = %v", got)
+	}
+}
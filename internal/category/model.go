@@ -6,12 +6,17 @@ import (
 
 // Category represents a category for organizing listings.
 type Category struct {
-	ID          string    `json:"id"` // UUID
-	Name        string    `json:"name"`
-	Slug        string    `json:"slug"` // URL-friendly version of the name
-	Description string    `json:"description,omitempty"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
-	// ParentCategoryID string `json:"parent_category_id,omitempty"` // For sub-categories, if needed later
+	ID   string `json:"id"` // UUID
+	Name string `json:"name"`
+	Slug string `json:"slug"` // URL-friendly version of the name
+	// OldSlugs are slugs the category had before being renamed. They stay
+	// reserved and redirect to Slug.
+	OldSlugs    []string `json:"old_slugs,omitempty"`
+	Description string   `json:"description,omitempty"`
+	// ParentCategoryID is empty for top-level categories. See tree.go for the
+	// rules a hierarchy must follow.
+	ParentCategoryID string    `json:"parent_category_id,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
 	// ListingCount int `json:"listing_count,omitempty"` // Could be a derived field
 }
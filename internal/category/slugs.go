@@ -0,0 +1,72 @@
+package category
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"seattle-info-platform/pkg/slug"
+)
+
+// ErrDuplicateSlug is returned when a category would take a slug, current or
+// old, that belongs to another category.
+var ErrDuplicateSlug = errors.New("category: slug already in use")
+
+// CheckSlug verifies that no category other than c uses one of c's slugs.
+// Stores call it after every write.
+func CheckSlug(categories []Category, c *Category) error {
+	for _, other := range categories {
+		if other.ID == c.ID {
+			continue
+		}
+		for _, s := range c.slugs() {
+			if other.hasSlug(s) {
+				return fmt.Errorf("%w: %s is used by %s", ErrDuplicateSlug, s, other.ID)
+			}
+		}
+	}
+	return nil
+}
+
+// FindBySlug returns the category with slug s and whether s is its current
+// slug (false means s is an old slug that should redirect).
+func FindBySlug(categories []Category, s string) (c *Category, current bool) {
+	for i := range categories {
+		if categories[i].Slug == s {
+			return &categories[i], true
+		}
+	}
+	for i := range categories {
+		if slices.Contains(categories[i].OldSlugs, s) {
+			return &categories[i], false
+		}
+	}
+	return nil, false
+}
+
+// SetName renames c and gives it the first free slug for the new name,
+// keeping the previous slug as a redirect. A slug in c's own history may be
+// reused. taken reports whether another category holds a slug.
+func (c *Category) SetName(name string, taken func(string) bool) {
+	c.Name = name
+	next := slug.Unique(slug.Make(name), taken)
+	if next == c.Slug {
+		return
+	}
+	// A new slice: c.OldSlugs may share its array with a stored row or an
+	// audit snapshot.
+	old := slices.Clone(c.OldSlugs)
+	if c.Slug != "" {
+		old = append(old, c.Slug)
+	}
+	c.OldSlugs = slices.DeleteFunc(old, func(s string) bool { return s == next })
+	c.Slug = next
+}
+
+func (c *Category) slugs() []string {
+	return append([]string{c.Slug}, c.OldSlugs...)
+}
+
+func (c *Category) hasSlug(s string) bool {
+	return c.Slug == s || slices.Contains(c.OldSlugs, s)
+}
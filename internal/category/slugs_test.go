@@ -0,0 +1,49 @@
+package category
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSetName(t *testing.T) {
+	taken := func(s string) bool { return s == "books" }
+	for _, tc := range []struct {
+		name     string
+		before   Category
+		newName  string
+		slug     string
+		oldSlugs []string
+	}{
+		{"first name", Category{}, "Home & Garden", "home-garden", nil},
+		{"rename keeps the old slug", Category{Slug: "tools"}, "Hardware", "hardware", []string{"tools"}},
+		{"same slug", Category{Slug: "tools", OldSlugs: []string{"kit"}}, "TOOLS", "tools", []string{"kit"}},
+		{"back to an old slug", Category{Slug: "gamma", OldSlugs: []string{"alpha", "beta"}}, "Alpha", "alpha", []string{"beta", "gamma"}},
+		{"taken slug", Category{Slug: "tools"}, "Books", "books-2", []string{"tools"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := tc.before
+			c.SetName(tc.newName, taken)
+			if c.Name != tc.newName || c.Slug != tc.slug || !reflect.DeepEqual(c.OldSlugs, tc.oldSlugs) {
+				t.Errorf("SetName(%q) = %q %q, want %q %q", tc.newName, c.Slug, c.OldSlugs, tc.slug, tc.oldSlugs)
+			}
+		})
+	}
+}
+
+// TestSetNameCopiesOldSlugs renames a category whose OldSlugs has spare
+// capacity, as rows loaded from a store do, and checks that a copy taken
+// before, such as an audit snapshot, keeps its slugs.
+func TestSetNameCopiesOldSlugs(t *testing.T) {
+	olds := make([]string, 2, 4)
+	copy(olds, []string{"alpha", "beta"})
+	c := Category{Slug: "gamma", OldSlugs: olds}
+	before := c
+
+	c.SetName("Alpha", func(string) bool { return false })
+	if want := []string{"beta", "gamma"}; !reflect.DeepEqual(c.OldSlugs, want) {
+		t.Errorf("OldSlugs = %q, want %q", c.OldSlugs, want)
+	}
+	if want := []string{"alpha", "beta"}; !reflect.DeepEqual(before.OldSlugs, want) {
+		t.Errorf("snapshot OldSlugs = %q, want %q unchanged", before.OldSlugs, want)
+	}
+}
@@ -0,0 +1,95 @@
+package category
+
+import (
+	"errors"
+	"fmt"
+)
+
+// MaxDepth is the deepest a category may be nested. Top-level categories are
+// at depth 1, so Housing → Rentals → Rooms reaches depth 3.
+const MaxDepth = 4
+
+var (
+	// ErrParentNotFound is returned when ParentCategoryID names a category
+	// that does not exist.
+	ErrParentNotFound = errors.New("category: parent category not found")
+	// ErrCycle is returned when a category would become its own ancestor.
+	ErrCycle = errors.New("category: parent would create a cycle")
+	// ErrTooDeep is returned when a category would be nested deeper than
+	// MaxDepth.
+	ErrTooDeep = errors.New("category: nesting too deep")
+)
+
+// CheckHierarchy verifies that every parent exists, that there are no cycles
+// and that no category is deeper than MaxDepth. Stores call it after every
+// write so a bad parent never gets persisted.
+func CheckHierarchy(categories []Category) error {
+	parent := make(map[string]string, len(categories))
+	for _, c := range categories {
+		parent[c.ID] = c.ParentCategoryID
+	}
+	for _, c := range categories {
+		depth := 1
+		seen := map[string]bool{c.ID: true}
+		for p := c.ParentCategoryID; p != ""; p = parent[p] {
+			if _, ok := parent[p]; !ok {
+				return fmt.Errorf("%w: %s has parent %s", ErrParentNotFound, c.ID, p)
+			}
+			if seen[p] {
+				return fmt.Errorf("%w: %s", ErrCycle, c.ID)
+			}
+			seen[p] = true
+			if depth++; depth > MaxDepth {
+				return fmt.Errorf("%w: %s is deeper than %d levels", ErrTooDeep, c.ID, MaxDepth)
+			}
+		}
+	}
+	return nil
+}
+
+// Descendants returns the ID of root and of every category below it.
+func Descendants(categories []Category, root string) map[string]bool {
+	children := make(map[string][]string)
+	for _, c := range categories {
+		children[c.ParentCategoryID] = append(children[c.ParentCategoryID], c.ID)
+	}
+	out := map[string]bool{root: true}
+	queue := []string{root}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, child := range children[id] {
+			if !out[child] {
+				out[child] = true
+				queue = append(queue, child)
+			}
+		}
+	}
+	return out
+}
+
+// Node is a category together with its subcategories.
+type Node struct {
+	Category
+	Children []*Node `json:"children"`
+}
+
+// BuildTree arranges categories into trees rooted at the top-level
+// categories. Siblings keep the order they have in categories. Categories
+// whose parent is missing are treated as top-level.
+func BuildTree(categories []Category) []*Node {
+	nodes := make(map[string]*Node, len(categories))
+	for _, c := range categories {
+		nodes[c.ID] = &Node{Category: c, Children: []*Node{}}
+	}
+	roots := []*Node{}
+	for _, c := range categories {
+		n := nodes[c.ID]
+		if p, ok := nodes[c.ParentCategoryID]; ok && c.ParentCategoryID != c.ID {
+			p.Children = append(p.Children, n)
+		} else {
+			roots = append(roots, n)
+		}
+	}
+	return roots
+}
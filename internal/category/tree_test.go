@@ -0,0 +1,77 @@
+package category
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// chain returns categories c1 → c2 → … → cn, each the parent of the next.
+func chain(n int) []Category {
+	out := make([]Category, n)
+	for i := range out {
+		out[i].ID = string(rune('a' + i))
+		if i > 0 {
+			out[i].ParentCategoryID = out[i-1].ID
+		}
+	}
+	return out
+}
+
+func TestCheckHierarchy(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		categories []Category
+		want       error
+	}{
+		{"empty", nil, nil},
+		{"flat", []Category{{ID: "a"}, {ID: "b"}}, nil},
+		{"max depth", chain(MaxDepth), nil},
+		{"too deep", chain(MaxDepth + 1), ErrTooDeep},
+		{"missing parent", []Category{{ID: "a", ParentCategoryID: "x"}}, ErrParentNotFound},
+		{"own parent", []Category{{ID: "a", ParentCategoryID: "a"}}, ErrCycle},
+		{"cycle", []Category{{ID: "a", ParentCategoryID: "b"}, {ID: "b", ParentCategoryID: "a"}}, ErrCycle},
+		{"cycle below a root", []Category{
+			{ID: "root"},
+			{ID: "a", ParentCategoryID: "c"},
+			{ID: "b", ParentCategoryID: "a"},
+			{ID: "c", ParentCategoryID: "b"},
+		}, ErrCycle},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := CheckHierarchy(tc.categories); !errors.Is(err, tc.want) {
+				t.Errorf("CheckHierarchy = %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestDescendants(t *testing.T) {
+	categories := append(chain(3), Category{ID: "x"}, Category{ID: "y", ParentCategoryID: "a"})
+	want := map[string]bool{"b": true, "c": true}
+	if got := Descendants(categories, "b"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Descendants(b) = %v, want %v", got, want)
+	}
+	if got := Descendants(categories, "a"); len(got) != 4 || got["x"] {
+		t.Errorf("Descendants(a) = %v", got)
+	}
+}
+
+func TestBuildTree(t *testing.T) {
+	roots := BuildTree([]Category{
+		{ID: "b", ParentCategoryID: "a"},
+		{ID: "a"},
+		{ID: "orphan", ParentCategoryID: "gone"},
+		{ID: "c", ParentCategoryID: "a"},
+	})
+	var ids []string
+	for _, n := range roots {
+		ids = append(ids, n.ID)
+	}
+	if !reflect.DeepEqual(ids, []string{"a", "orphan"}) {
+		t.Fatalf("roots = %q, want a and orphan", ids)
+	}
+	if kids := roots[0].Children; len(kids) != 2 || kids[0].ID != "b" || kids[1].ID != "c" {
+		t.Errorf("children of a = %+v, want b and c in order", kids)
+	}
+}
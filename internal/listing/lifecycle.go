@@ -0,0 +1,101 @@
+package listing
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Actor identifies who is changing a listing's status.
+type Actor string
+
+const (
+	ActorAdmin     Actor = "admin"
+	ActorModerator Actor = "moderator"
+	ActorSubmitter Actor = "submitter" // The user who posted the listing
+	ActorSystem    Actor = "system"    // Background jobs such as expiry
+)
+
+// Transitions lists, for each status, the statuses a listing may move to and
+// the actors allowed to make each move.
+var Transitions = map[ListingStatus]map[ListingStatus][]Actor{
+	StatusPendingApproval: {
+		StatusActive:       {ActorAdmin, ActorModerator},
+		StatusRejected:     {ActorAdmin, ActorModerator},
+		StatusAdminRemoved: {ActorAdmin, ActorModerator},
+	},
+	StatusActive: {
+		StatusExpired:      {ActorAdmin, ActorSystem},
+		StatusAdminRemoved: {ActorAdmin, ActorModerator},
+	},
+	StatusRejected: {
+		StatusPendingApproval: {ActorSubmitter}, // Resubmission after edits
+		StatusAdminRemoved:    {ActorAdmin, ActorModerator},
+	},
+	StatusExpired: {
+		StatusAdminRemoved: {ActorAdmin, ActorModerator},
+	},
+	StatusAdminRemoved: {
+		StatusActive: {ActorAdmin}, // Restore a listing removed in error
+	},
+}
+
+// ErrReasonRequired is returned when rejecting a listing without a reason.
+var ErrReasonRequired = errors.New("listing: a rejection reason is required")
+
+// TransitionError reports a status change that Transitions does not allow
+// for the actor.
+type TransitionError struct {
+	From, To ListingStatus
+	Actor    Actor
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("listing: %s cannot change status from %q to %q", e.Actor, e.From, e.To)
+}
+
+// Allowed returns the statuses e.Actor may move a listing to from e.From.
+func (e *TransitionError) Allowed() []ListingStatus {
+	return NextStatuses(e.From, e.Actor)
+}
+
+// NextStatuses returns the statuses actor may move a listing in status from
+// to, in a stable order.
+func NextStatuses(from ListingStatus, actor Actor) []ListingStatus {
+	out := []ListingStatus{}
+	for _, to := range []ListingStatus{StatusPendingApproval, StatusActive, StatusRejected, StatusExpired, StatusAdminRemoved} {
+		if CanTransition(from, to, actor) {
+			out = append(out, to)
+		}
+	}
+	return out
+}
+
+// CanTransition reports whether actor may move a listing from status from to
+// status to.
+func CanTransition(from, to ListingStatus, actor Actor) bool {
+	for _, a := range Transitions[from][to] {
+		if a == actor {
+			return true
+		}
+	}
+	return false
+}
+
+// Transition moves l to status to on behalf of actor. A rejection must carry
+// a reason; any other status clears the previous one.
+func (l *Listing) Transition(to ListingStatus, actor Actor, reason string) error {
+	if !CanTransition(l.Status, to, actor) {
+		return &TransitionError{From: l.Status, To: to, Actor: actor}
+	}
+	reason = strings.TrimSpace(reason)
+	if to == StatusRejected && reason == "" {
+		return ErrReasonRequired
+	}
+	if to != StatusRejected {
+		reason = ""
+	}
+	l.Status = to
+	l.RejectionReason = reason
+	return nil
+}
@@ -0,0 +1,124 @@
+package listing
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// TestCanTransition walks every status pair for every actor against the
+// moderation rules, written out move by move.
+func TestCanTransition(t *testing.T) {
+	type move struct {
+		from, to ListingStatus
+		actor    Actor
+	}
+	allowed := map[move]bool{
+		{StatusPendingApproval, StatusActive, ActorAdmin}:           true,
+		{StatusPendingApproval, StatusActive, ActorModerator}:       true,
+		{StatusPendingApproval, StatusRejected, ActorAdmin}:         true,
+		{StatusPendingApproval, StatusRejected, ActorModerator}:     true,
+		{StatusPendingApproval, StatusAdminRemoved, ActorAdmin}:     true,
+		{StatusPendingApproval, StatusAdminRemoved, ActorModerator}: true,
+		{StatusActive, StatusExpired, ActorAdmin}:                   true,
+		{StatusActive, StatusExpired, ActorSystem}:                  true,
+		{StatusActive, StatusAdminRemoved, ActorAdmin}:              true,
+		{StatusActive, StatusAdminRemoved, ActorModerator}:          true,
+		{StatusRejected, StatusPendingApproval, ActorSubmitter}:     true,
+		{StatusRejected, StatusAdminRemoved, ActorAdmin}:            true,
+		{StatusRejected, StatusAdminRemoved, ActorModerator}:        true,
+		{StatusExpired, StatusAdminRemoved, ActorAdmin}:             true,
+		{StatusExpired, StatusAdminRemoved, ActorModerator}:         true,
+		{StatusAdminRemoved, StatusActive, ActorAdmin}:              true,
+	}
+	statuses := []ListingStatus{StatusPendingApproval, StatusActive, StatusRejected, StatusExpired, StatusAdminRemoved}
+	for _, from := range statuses {
+		for _, to := range statuses {
+			for _, actor := range []Actor{ActorAdmin, ActorModerator, ActorSubmitter, ActorSystem, "bogus"} {
+				m := move{from, to, actor}
+				if got := CanTransition(from, to, actor); got != allowed[m] {
+					t.Errorf("CanTransition(%q, %q, %q) = %v", from, to, actor, got)
+				}
+			}
+		}
+	}
+}
+
+func TestNextStatuses(t *testing.T) {
+	for _, tc := range []struct {
+		from  ListingStatus
+		actor Actor
+		want  []ListingStatus
+	}{
+		{StatusPendingApproval, ActorModerator, []ListingStatus{StatusActive, StatusRejected, StatusAdminRemoved}},
+		{StatusPendingApproval, ActorSubmitter, []ListingStatus{}},
+		{StatusActive, ActorAdmin, []ListingStatus{StatusExpired, StatusAdminRemoved}},
+		{StatusActive, ActorSystem, []ListingStatus{StatusExpired}},
+		{StatusRejected, ActorSubmitter, []ListingStatus{StatusPendingApproval}},
+		{StatusAdminRemoved, ActorModerator, []ListingStatus{}},
+		{StatusAdminRemoved, ActorAdmin, []ListingStatus{StatusActive}},
+	} {
+		if got := NextStatuses(tc.from, tc.actor); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("NextStatuses(%q, %q) = %q, want %q", tc.from, tc.actor, got, tc.want)
+		}
+	}
+}
+
+func TestTransition(t *testing.T) {
+	// A refused move leaves the listing as it was, reason included.
+	rejected := Listing{Status: StatusRejected, RejectionReason: "blurry photos"}
+	for _, tc := range []struct {
+		name   string
+		before Listing
+		to     ListingStatus
+		actor  Actor
+		reason string
+		after  Listing
+		err    error
+	}{
+		{"approve", Listing{Status: StatusPendingApproval}, StatusActive, ActorModerator, "",
+			Listing{Status: StatusActive}, nil},
+		{"reject trims the reason", Listing{Status: StatusPendingApproval}, StatusRejected, ActorAdmin, " blurry photos ",
+			rejected, nil},
+		{"reject needs a reason", Listing{Status: StatusPendingApproval}, StatusRejected, ActorAdmin, " ",
+			Listing{Status: StatusPendingApproval}, ErrReasonRequired},
+		{"resubmit clears the reason", rejected, StatusPendingApproval, ActorSubmitter, "fixed",
+			Listing{Status: StatusPendingApproval}, nil},
+		{"expire", Listing{Status: StatusActive}, StatusExpired, ActorSystem, "",
+			Listing{Status: StatusExpired}, nil},
+		{"restore", Listing{Status: StatusAdminRemoved}, StatusActive, ActorAdmin, "",
+			Listing{Status: StatusActive}, nil},
+		{"moderator cannot restore", Listing{Status: StatusAdminRemoved}, StatusActive, ActorModerator, "",
+			Listing{Status: StatusAdminRemoved}, &TransitionError{From: StatusAdminRemoved, To: StatusActive, Actor: ActorModerator}},
+		{"submitter cannot approve", Listing{Status: StatusPendingApproval}, StatusActive, ActorSubmitter, "",
+			Listing{Status: StatusPendingApproval}, &TransitionError{From: StatusPendingApproval, To: StatusActive, Actor: ActorSubmitter}},
+		{"refusal keeps the reason", rejected, StatusActive, ActorAdmin, "",
+			rejected, &TransitionError{From: StatusRejected, To: StatusActive, Actor: ActorAdmin}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			l := tc.before
+			if err := l.Transition(tc.to, tc.actor, tc.reason); !reflect.DeepEqual(err, tc.err) {
+				t.Errorf("Transition = %v, want %v", err, tc.err)
+			}
+			if l != tc.after {
+				t.Errorf("listing = %+v, want %+v", l, tc.after)
+			}
+		})
+	}
+}
+
+func TestTransitionErrorAllowed(t *testing.T) {
+	l := &Listing{Status: StatusActive}
+	err := l.Transition(StatusRejected, ActorModerator, "late")
+	var te *TransitionError
+	if !errors.As(err, &te) {
+		t.Fatalf("Transition = %v, want a *TransitionError", err)
+	}
+	// The handlers offer these to the moderator instead.
+	if want := []ListingStatus{StatusAdminRemoved}; !reflect.DeepEqual(te.Allowed(), want) {
+		t.Errorf("Allowed = %q, want %q", te.Allowed(), want)
+	}
+	if want := `listing: moderator cannot change status from "active" to "rejected"`; err.Error() != want {
+		t.Errorf("Error = %q, want %q", err, want)
+	}
+}
@@ -1,9 +1,53 @@
 package listing
 
-import "time"
+import (
+	"context"
+	"time"
 
-// This file can be used for listing service logic in the future.
-// For now, it just ensures the package is valid.
+	"seattle-info-platform/internal/audit"
+)
+
+// Store is the persistence the Service needs. database.ListingRepository
+// satisfies it.
+type Store interface {
+	// UpdateFunc atomically loads the listing, applies fn and stores the result.
+	UpdateFunc(ctx context.Context, id string, fn func(*Listing) error) (*Listing, error)
+}
+
+// Service applies listing status changes, enforcing Transitions, and
+// records each one in the audit log. A change whose record fails is not
+// stored.
+type Service struct {
+	store Store
+	audit audit.Recorder
+	now   func() time.Time
+}
+
+// NewService returns a service that persists changes in store and records
+// them with rec.
+func NewService(store Store, rec audit.Recorder) *Service {
+	return &Service{store: store, audit: rec, now: time.Now}
+}
+
+// SetStatus moves the listing to status on behalf of actor. reason is
+// required when rejecting and ignored otherwise.
+func (s *Service) SetStatus(ctx context.Context, id string, status ListingStatus, actor Actor, reason string) (*Listing, error) {
+	return s.store.UpdateFunc(ctx, id, func(l *Listing) error {
+		before := *l
+		if err := l.Transition(status, actor, reason); err != nil {
+			return err
+		}
+		now := s.now()
+		l.LastUpdatedDate = now
+		l.UpdatedAt = now
+		// Recorded in the same atomic write, so a change that cannot be
+		// recorded is not stored either.
+		return s.audit.Record(ctx, audit.Event{
+			Action: "listing.set_status", TargetType: audit.TargetListing, TargetID: id, Reason: reason,
+			Before: &before, After: l,
+		})
+	})
+}
 
 func GetMockListing(id string, userID string, categoryID string) *Listing {
 	// This is a mock function. In a real application, you would fetch this from a database.
@@ -15,6 +15,15 @@ const (
 	StatusAdminRemoved    ListingStatus = "admin_removed"
 )
 
+// IsValid reports whether s is one of the known statuses.
+func (s ListingStatus) IsValid() bool {
+	switch s {
+	case StatusPendingApproval, StatusActive, StatusRejected, StatusExpired, StatusAdminRemoved:
+		return true
+	}
+	return false
+}
+
 // Listing represents an item or piece of information on the platform.
 type Listing struct {
 	ID              string        `json:"id"` // UUID
@@ -1,3 +1,32 @@
+// Package auth verifies Firebase ID tokens presented by API clients and makes
+// the verified caller available to handlers through the request context.
 package auth
 
-// TODO: Implement auth related logic
+import (
+	"context"
+	"time"
+)
+
+// Principal is the caller identified by a verified Firebase ID token.
+type Principal struct {
+	UID            string         `json:"uid"`
+	Email          string         `json:"email,omitempty"`
+	EmailVerified  bool           `json:"email_verified"`
+	SignInProvider string         `json:"sign_in_provider,omitempty"`
+	IssuedAt       time.Time      `json:"issued_at"`
+	ExpiresAt      time.Time      `json:"expires_at"`
+	Claims         map[string]any `json:"-"` // Every claim in the token, including custom claims
+}
+
+type principalKey struct{}
+
+// NewContext returns a copy of ctx carrying p.
+func NewContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the principal stored in ctx, if any.
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok && p != nil
+}
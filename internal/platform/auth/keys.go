@@ -0,0 +1,369 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultKeyTTL is used when a source does not say how long keys are valid.
+	defaultKeyTTL = time.Hour
+	// minRefreshInterval bounds how often an unknown key ID may force a refresh.
+	minRefreshInterval = time.Minute
+	// fetchTimeout bounds one fetch from a KeySource.
+	fetchTimeout = 10 * time.Second
+	// After a failed fetch the next one waits minRetryBackoff, doubling with
+	// each further failure up to maxRetryBackoff.
+	minRetryBackoff = time.Second
+	maxRetryBackoff = 5 * time.Minute
+	// maxStale is how long expired keys stay in use while fetching new ones
+	// fails.
+	maxStale = 24 * time.Hour
+)
+
+// ErrUnknownKey is returned when a token names a key that is not in the
+// current key set, even after refreshing it.
+var ErrUnknownKey = errors.New("auth: unknown signing key")
+
+// KeySource fetches the current set of token signing keys by key ID, along
+// with how long the set may be cached. A zero TTL means "use the default".
+type KeySource interface {
+	FetchKeys(ctx context.Context) (map[string]*rsa.PublicKey, time.Duration, error)
+}
+
+// HTTPKeySource fetches keys from a URL serving either a JSON object of
+// key ID to PEM certificate (Google's x509 format) or a JWKS document. The
+// cache lifetime comes from the response's Cache-Control max-age.
+type HTTPKeySource struct {
+	URL    string
+	Client *http.Client // http.DefaultClient if nil
+}
+
+func (s HTTPKeySource) FetchKeys(ctx context.Context) (map[string]*rsa.PublicKey, time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("auth: build key request: %w", err)
+	}
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("auth: fetch keys: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("auth: fetch keys: unexpected status %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, 0, fmt.Errorf("auth: read keys: %w", err)
+	}
+	keys, err := ParseKeys(data)
+	if err != nil {
+		return nil, 0, err
+	}
+	return keys, maxAge(resp.Header.Get("Cache-Control")), nil
+}
+
+// FileKeySource reads keys from a local file in the same formats as
+// HTTPKeySource. It is meant for offline development and tests; the file is
+// re-read every TTL so rotated keys are picked up without a restart.
+type FileKeySource struct {
+	Path string
+	TTL  time.Duration
+}
+
+func (s FileKeySource) FetchKeys(ctx context.Context) (map[string]*rsa.PublicKey, time.Duration, error) {
+	data, err := os.ReadFile(s.Path)
+	if err != nil {
+		return nil, 0, fmt.Errorf("auth: read keys: %w", err)
+	}
+	keys, err := ParseKeys(data)
+	if err != nil {
+		return nil, 0, err
+	}
+	return keys, s.TTL, nil
+}
+
+// ParseKeys decodes a key set that is either a JSON object mapping key IDs to
+// PEM-encoded x509 certificates or a JWKS document with RSA keys.
+func ParseKeys(data []byte) (map[string]*rsa.PublicKey, error) {
+	var jwks struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &jwks); err == nil && jwks.Keys != nil {
+		keys := make(map[string]*rsa.PublicKey, len(jwks.Keys))
+		for _, k := range jwks.Keys {
+			if k.Kty != "RSA" {
+				continue
+			}
+			pub, err := jwkToRSA(k.N, k.E)
+			if err != nil {
+				return nil, fmt.Errorf("auth: key %q: %w", k.Kid, err)
+			}
+			keys[k.Kid] = pub
+		}
+		if len(keys) == 0 {
+			return nil, errors.New("auth: key set contains no RSA keys")
+		}
+		return keys, nil
+	}
+
+	var certs map[string]string
+	if err := json.Unmarshal(data, &certs); err != nil {
+		return nil, fmt.Errorf("auth: decode key set: %w", err)
+	}
+	keys := make(map[string]*rsa.PublicKey, len(certs))
+	for kid, certPEM := range certs {
+		block, _ := pem.Decode([]byte(certPEM))
+		if block == nil {
+			return nil, fmt.Errorf("auth: key %q: no PEM data", kid)
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("auth: key %q: %w", kid, err)
+		}
+		pub, ok := cert.PublicKey.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("auth: key %q: not an RSA key", kid)
+		}
+		keys[kid] = pub
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("auth: key set is empty")
+	}
+	return keys, nil
+}
+
+func jwkToRSA(n, e string) (*rsa.PublicKey, error) {
+	nb, err := base64.RawURLEncoding.DecodeString(n)
+	if err != nil {
+		return nil, fmt.Errorf("decode modulus: %w", err)
+	}
+	eb, err := base64.RawURLEncoding.DecodeString(e)
+	if err != nil {
+		return nil, fmt.Errorf("decode exponent: %w", err)
+	}
+	exp := new(big.Int).SetBytes(eb)
+	if !exp.IsInt64() || exp.Int64() > 1<<31-1 || exp.Int64() < 3 {
+		return nil, errors.New("invalid exponent")
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(nb), E: int(exp.Int64())}, nil
+}
+
+// maxAge extracts max-age from a Cache-Control header, or 0 if absent.
+func maxAge(cacheControl string) time.Duration {
+	for _, directive := range strings.Split(cacheControl, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(directive), "=")
+		if !ok || !strings.EqualFold(name, "max-age") {
+			continue
+		}
+		secs, err := strconv.Atoi(value)
+		if err != nil || secs <= 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	return 0
+}
+
+// KeyCache caches the keys of a KeySource until they expire. A token signed
+// with a key ID that is not cached triggers an early refresh, so rotated keys
+// are picked up immediately, but at most once per minRefreshInterval.
+//
+// Fetches run without holding the cache lock, and concurrent callers share
+// one fetch. If a fetch fails, the expired keys stay in use for up to
+// maxStale while retries back off exponentially, so an outage of the source
+// neither blocks nor floods it.
+type KeyCache struct {
+	source KeySource
+	now    func() time.Time
+
+	mu          sync.RWMutex
+	keys        map[string]*rsa.PublicKey
+	expires     time.Time
+	lastRefresh time.Time // start of the last fetch
+	failures    int       // consecutive failed fetches
+	retryAt     time.Time // no fetch before this after a failure
+	lastErr     error
+	inflight    *keyFetch
+}
+
+// keyFetch is a fetch in progress; done is closed once err is set.
+type keyFetch struct {
+	done chan struct{}
+	err  error
+}
+
+// NewKeyCache returns an empty cache over source.
+func NewKeyCache(source KeySource) *KeyCache {
+	return &KeyCache{source: source, now: time.Now}
+}
+
+// Key returns the public key with the given key ID.
+func (c *KeyCache) Key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	now := c.now()
+	c.mu.RLock()
+	key, ok := c.keys[kid]
+	fresh := now.Before(c.expires)
+	c.mu.RUnlock()
+	if ok && fresh {
+		return key, nil
+	}
+
+	c.mu.Lock()
+	var f *keyFetch
+	if c.dueLocked(now, !fresh || now.Sub(c.lastRefresh) >= minRefreshInterval) {
+		f = c.fetchLocked(ctx, now)
+	}
+	c.mu.Unlock()
+	if f != nil {
+		if err := wait(ctx, f); err != nil && !c.usable(now) {
+			return nil, err
+		}
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	key, ok = c.keys[kid]
+	switch {
+	case ok && c.usableLocked(c.now()):
+		return key, nil
+	case !c.usableLocked(c.now()) && c.lastErr != nil:
+		return nil, fmt.Errorf("auth: signing keys unavailable: %w", c.lastErr)
+	}
+	return nil, fmt.Errorf("%w %q", ErrUnknownKey, kid)
+}
+
+// Warm reports whether the cache holds usable keys, fetching them first if
+// they have expired. Fetches back off after failures, so frequent callers
+// such as readiness probes cannot hammer the source.
+func (c *KeyCache) Warm(ctx context.Context) error {
+	now := c.now()
+	c.mu.Lock()
+	if len(c.keys) > 0 && now.Before(c.expires) {
+		c.mu.Unlock()
+		return nil
+	}
+	var f *keyFetch
+	if c.dueLocked(now, true) {
+		f = c.fetchLocked(ctx, now)
+	}
+	c.mu.Unlock()
+	if f != nil {
+		if err := wait(ctx, f); err != nil && !c.usable(now) {
+			return err
+		}
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.usableLocked(c.now()) {
+		return nil
+	}
+	if c.lastErr != nil {
+		return fmt.Errorf("auth: signing keys not loaded; retrying at %s: %w", c.retryAt.Format(time.RFC3339), c.lastErr)
+	}
+	return errors.New("auth: signing keys not loaded; retrying shortly")
+}
+
+// Refresh fetches the key set from the source immediately, ignoring any
+// backoff.
+func (c *KeyCache) Refresh(ctx context.Context) error {
+	c.mu.Lock()
+	f := c.fetchLocked(ctx, c.now())
+	c.mu.Unlock()
+	return wait(ctx, f)
+}
+
+// dueLocked reports whether a fetch may start now, given whether one is
+// wanted: a fetch in progress is always joined, and failed fetches are not
+// retried before their backoff has passed.
+func (c *KeyCache) dueLocked(now time.Time, wanted bool) bool {
+	return c.inflight != nil || (wanted && !now.Before(c.retryAt))
+}
+
+// usable reports whether the cached keys may still verify tokens: until
+// they expire, and for maxStale after if fetching new ones keeps failing.
+func (c *KeyCache) usable(now time.Time) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.usableLocked(now)
+}
+
+func (c *KeyCache) usableLocked(now time.Time) bool {
+	return len(c.keys) > 0 && now.Before(c.expires.Add(maxStale))
+}
+
+// fetchLocked starts a fetch, or returns the one in progress.
+func (c *KeyCache) fetchLocked(ctx context.Context, now time.Time) *keyFetch {
+	if c.inflight != nil {
+		return c.inflight
+	}
+	f := &keyFetch{done: make(chan struct{})}
+	c.inflight = f
+	c.lastRefresh = now
+	// Detached from the caller's cancellation: other callers may be waiting
+	// for the same fetch.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), fetchTimeout)
+	go func() {
+		defer cancel()
+		keys, ttl, err := c.source.FetchKeys(ctx)
+		now := c.now()
+		c.mu.Lock()
+		if err != nil {
+			c.failures++
+			c.retryAt = now.Add(retryBackoff(c.failures))
+			c.lastErr = err
+		} else {
+			if ttl <= 0 {
+				ttl = defaultKeyTTL
+			}
+			c.keys, c.expires = keys, now.Add(ttl)
+			c.failures, c.retryAt, c.lastErr = 0, time.Time{}, nil
+		}
+		c.inflight = nil
+		f.err = err
+		c.mu.Unlock()
+		close(f.done)
+	}()
+	return f
+}
+
+// wait waits for f to finish or ctx to be done.
+func wait(ctx context.Context, f *keyFetch) error {
+	select {
+	case <-f.done:
+		return f.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// retryBackoff is the delay before the next fetch after failures
+// consecutive failed ones.
+func retryBackoff(failures int) time.Duration {
+	d := minRetryBackoff << min(failures-1, 16)
+	return min(d, maxRetryBackoff)
+}
@@ -0,0 +1,204 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseKeysX509(t *testing.T) {
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "securetoken"},
+		NotBefore:    testNow.Add(-time.Hour),
+		NotAfter:     testNow.Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &testKey.PublicKey, testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(map[string]string{
+		"k1": string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+	})
+	keys, err := ParseKeys(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !keys["k1"].Equal(&testKey.PublicKey) {
+		t.Error("certificate key does not match")
+	}
+
+	for _, bad := range []string{`{}`, `{"k1":"not pem"}`, `{"keys":[]}`, `[`} {
+		if _, err := ParseKeys([]byte(bad)); err == nil {
+			t.Errorf("ParseKeys(%s) succeeded", bad)
+		}
+	}
+}
+
+// fakeSource serves a key set, or fails, and counts fetches. Each fetch
+// waits for release if it is set.
+type fakeSource struct {
+	mu      sync.Mutex
+	err     error
+	release chan struct{}
+	fetches atomic.Int32
+}
+
+func (s *fakeSource) FetchKeys(ctx context.Context) (map[string]*rsa.PublicKey, time.Duration, error) {
+	s.fetches.Add(1)
+	s.mu.Lock()
+	release, err := s.release, s.err
+	s.mu.Unlock()
+	if release != nil {
+		<-release
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	return map[string]*rsa.PublicKey{"k1": &testKey.PublicKey}, time.Hour, nil
+}
+
+func (s *fakeSource) fail(err error) {
+	s.mu.Lock()
+	s.err = err
+	s.mu.Unlock()
+}
+
+// clock is a settable time source.
+type clock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func (c *clock) now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *clock) advance(d time.Duration) {
+	c.mu.Lock()
+	c.t = c.t.Add(d)
+	c.mu.Unlock()
+}
+
+func TestKeyCacheSharesOneFetch(t *testing.T) {
+	src := &fakeSource{release: make(chan struct{})}
+	cache := NewKeyCache(src)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := cache.Key(context.Background(), "k1"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	// The cache is not locked while the fetch is in progress.
+	for src.fetches.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if !cache.mu.TryLock() {
+		t.Error("cache locked during fetch")
+	} else {
+		cache.mu.Unlock()
+	}
+	close(src.release)
+	wg.Wait()
+	if n := src.fetches.Load(); n != 1 {
+		t.Errorf("%d fetches, want 1", n)
+	}
+}
+
+func TestKeyCacheServesStaleKeysWithBackoff(t *testing.T) {
+	src := &fakeSource{}
+	clk := &clock{t: testNow}
+	cache := NewKeyCache(src)
+	cache.now = clk.now
+	ctx := context.Background()
+
+	if _, err := cache.Key(ctx, "k1"); err != nil {
+		t.Fatal(err)
+	}
+	// The keys expire and the source goes down: the stale key is served,
+	// and the source is retried only once the backoff has passed.
+	src.fail(errors.New("source down"))
+	clk.advance(2 * time.Hour)
+	for i := 0; i < 5; i++ {
+		if _, err := cache.Key(ctx, "k1"); err != nil {
+			t.Fatalf("stale key: %v", err)
+		}
+	}
+	if n := src.fetches.Load(); n != 2 {
+		t.Fatalf("%d fetches, want 2", n)
+	}
+	if err := cache.Warm(ctx); err != nil {
+		t.Errorf("Warm with stale keys: %v", err)
+	}
+	clk.advance(minRetryBackoff)
+	cache.Key(ctx, "k1")
+	if n := src.fetches.Load(); n != 3 {
+		t.Fatalf("after backoff: %d fetches, want 3", n)
+	}
+	// The backoff has doubled.
+	clk.advance(minRetryBackoff)
+	cache.Key(ctx, "k1")
+	if n := src.fetches.Load(); n != 3 {
+		t.Fatalf("within doubled backoff: %d fetches, want 3", n)
+	}
+
+	// Unknown key IDs are not served from a stale set either.
+	if _, err := cache.Key(ctx, "k2"); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("unknown key: got %v, want ErrUnknownKey", err)
+	}
+
+	// Past maxStale the keys are no longer trusted.
+	clk.advance(maxStale)
+	if _, err := cache.Key(ctx, "k1"); err == nil || errors.Is(err, ErrUnknownKey) {
+		t.Errorf("beyond maxStale: got %v, want the source error", err)
+	}
+	if err := cache.Warm(ctx); err == nil {
+		t.Error("Warm beyond maxStale succeeded")
+	}
+
+	// Once the source recovers the keys are fresh again.
+	src.fail(nil)
+	clk.advance(maxRetryBackoff)
+	if _, err := cache.Key(ctx, "k1"); err != nil {
+		t.Fatalf("after recovery: %v", err)
+	}
+}
+
+func TestKeyCacheUnknownKeyRefreshLimit(t *testing.T) {
+	src := &fakeSource{}
+	clk := &clock{t: testNow}
+	cache := NewKeyCache(src)
+	cache.now = clk.now
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := cache.Key(ctx, "rotated"); !errors.Is(err, ErrUnknownKey) {
+			t.Fatalf("got %v, want ErrUnknownKey", err)
+		}
+	}
+	if n := src.fetches.Load(); n != 1 {
+		t.Errorf("%d fetches within minRefreshInterval, want 1", n)
+	}
+	clk.advance(minRefreshInterval)
+	cache.Key(ctx, "rotated")
+	if n := src.fetches.Load(); n != 2 {
+		t.Errorf("%d fetches after minRefreshInterval, want 2", n)
+	}
+}
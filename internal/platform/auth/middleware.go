@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"seattle-info-platform/internal/platform/problem"
+)
+
+// Middleware rejects requests without a valid `Authorization: Bearer <Firebase
+// ID token>` header with 401 and stores the verified principal in the context
+// of the remaining requests. If the signing keys cannot be loaded the request
+// fails with 503 rather than being treated as unauthenticated.
+func Middleware(v *Verifier) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+			if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+				problem.Error(w, r, http.StatusUnauthorized, problem.CodeUnauthorized, "Missing bearer token")
+				return
+			}
+
+			p, err := v.Verify(r.Context(), strings.TrimSpace(token))
+			if errors.Is(err, ErrInvalidToken) {
+				slog.InfoContext(r.Context(), "token rejected", "err", err)
+				w.Header().Set("WWW-Authenticate", `Bearer realm="api", error="invalid_token"`)
+				problem.Error(w, r, http.StatusUnauthorized, problem.CodeUnauthorized, "Invalid or expired token")
+				return
+			}
+			if err != nil {
+				slog.ErrorContext(r.Context(), "could not verify token", "err", err)
+				problem.Error(w, r, http.StatusServiceUnavailable, problem.CodeUnavailable, "Unable to verify token")
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), p)))
+		})
+	}
+}
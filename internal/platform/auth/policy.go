@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"seattle-info-platform/internal/platform/logging"
+	"seattle-info-platform/internal/platform/problem"
+	"seattle-info-platform/internal/user"
+)
+
+// Permission names an action on the admin API.
+type Permission string
+
+const (
+	PermViewUsers        Permission = "users:read"
+	PermReviewUsers      Permission = "users:review"  // Approve or reject pending registrations
+	PermSuspendUsers     Permission = "users:suspend" // Suspend or reactivate accounts
+	PermDeactivateUsers  Permission = "users:deactivate"
+	PermChangeUserRoles  Permission = "users:change_role"
+	PermViewListings     Permission = "listings:read"
+	PermModerateListings Permission = "listings:moderate"
+	PermViewCategories   Permission = "categories:read"
+	PermManageCategories Permission = "categories:write"
+	PermViewAudit        Permission = "audit:read"
+)
+
+// Policy lists the permissions granted to each role. Roles that are absent
+// (such as user.RoleUser) have no access to the admin API at all. The user
+// permissions are further limited by user.Service: moderators only act on
+// user accounts, and nobody changes their own.
+var Policy = map[user.UserRole][]Permission{
+	user.RoleAdmin: {
+		PermViewUsers, PermReviewUsers, PermSuspendUsers, PermDeactivateUsers, PermChangeUserRoles,
+		PermViewListings, PermModerateListings,
+		PermViewCategories, PermManageCategories,
+		PermViewAudit,
+	},
+	user.RoleModerator: {
+		PermViewUsers, PermReviewUsers, PermSuspendUsers,
+		PermViewListings, PermModerateListings,
+		PermViewCategories,
+	},
+}
+
+// Allowed reports whether role has been granted perm by Policy.
+func Allowed(role user.UserRole, perm Permission) bool {
+	for _, p := range Policy[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrNoAccount is returned by an AccountLookup when the principal has no
+// platform account.
+var ErrNoAccount = errors.New("auth: no account for principal")
+
+// AccountLookup loads the platform account of a verified principal.
+type AccountLookup func(ctx context.Context, p *Principal) (*user.User, error)
+
+type accountKey struct{}
+
+// NewAccountContext returns a copy of ctx carrying the authorized account.
+func NewAccountContext(ctx context.Context, u *user.User) context.Context {
+	return context.WithValue(ctx, accountKey{}, u)
+}
+
+// AccountFromContext returns the authorized caller's account stored by
+// Authorize, if any.
+func AccountFromContext(ctx context.Context) (*user.User, bool) {
+	u, ok := ctx.Value(accountKey{}).(*user.User)
+	return u, ok && u != nil
+}
+
+// Authorize must run after Middleware. It loads the caller's account and
+// rejects with 403 anyone who is not an active user holding a role listed in
+// Policy. The account is stored in the request context for Require and for
+// handlers.
+func Authorize(lookup AccountLookup) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p, ok := FromContext(r.Context())
+			if !ok {
+				problem.Error(w, r, http.StatusUnauthorized, problem.CodeUnauthorized, "Authentication required")
+				return
+			}
+			account, err := lookup(r.Context(), p)
+			if errors.Is(err, ErrNoAccount) {
+				slog.InfoContext(r.Context(), "access denied: no account", "uid", p.UID)
+				problem.Error(w, r, http.StatusForbidden, problem.CodeForbidden, "You do not have permission to perform this action")
+				return
+			}
+			if err != nil {
+				slog.ErrorContext(r.Context(), "load account", "uid", p.UID, "err", err)
+				problem.Error(w, r, http.StatusInternalServerError, problem.CodeInternal, "Failed to load account")
+				return
+			}
+			if account.Status != user.StatusActive || len(Policy[account.Role]) == 0 {
+				slog.InfoContext(r.Context(), "access denied: inactive or unprivileged account", "user_id", account.ID, "role", account.Role, "status", account.Status)
+				problem.Error(w, r, http.StatusForbidden, problem.CodeForbidden, "You do not have permission to perform this action")
+				return
+			}
+			logging.Add(r.Context(), slog.String("admin", account.ID))
+			next.ServeHTTP(w, r.WithContext(NewAccountContext(r.Context(), account)))
+		})
+	}
+}
+
+// Require wraps h so that it only runs for callers whose role has perm.
+// It must run after Authorize.
+func Require(perm Permission, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		account, ok := AccountFromContext(r.Context())
+		if !ok || !Allowed(account.Role, perm) {
+			if ok {
+				slog.InfoContext(r.Context(), "access denied: missing permission", "role", account.Role, "permission", perm)
+			}
+			problem.Error(w, r, http.StatusForbidden, problem.CodeForbidden, "You do not have permission to perform this action")
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrInvalidToken is wrapped by every error returned for a token that is
+// malformed, badly signed, expired or issued for another project.
+var ErrInvalidToken = errors.New("auth: invalid token")
+
+// defaultLeeway absorbs small clock differences between us and Google.
+const defaultLeeway = 30 * time.Second
+
+// Verifier checks Firebase ID tokens issued for one Firebase project.
+type Verifier struct {
+	projectID string
+	keys      *KeyCache
+	leeway    time.Duration
+	now       func() time.Time
+}
+
+// NewVerifier returns a verifier for tokens of the given Firebase project,
+// checking signatures against keys.
+func NewVerifier(projectID string, keys *KeyCache) *Verifier {
+	return &Verifier{projectID: projectID, keys: keys, leeway: defaultLeeway, now: time.Now}
+}
+
+type tokenHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type tokenClaims struct {
+	Issuer   string          `json:"iss"`
+	Audience string          `json:"aud"`
+	Subject  string          `json:"sub"`
+	IssuedAt int64           `json:"iat"`
+	Expires  int64           `json:"exp"`
+	AuthTime int64           `json:"auth_time"`
+	Email    string          `json:"email"`
+	Verified bool            `json:"email_verified"`
+	Firebase json.RawMessage `json:"firebase"`
+}
+
+// Verify validates token and returns the principal it identifies. The token
+// must be an RS256 JWT signed by a current Firebase key whose aud is the
+// project ID, iss is the project's securetoken issuer, and whose exp, iat and
+// auth_time are consistent with the current time.
+func (v *Verifier) Verify(ctx context.Context, token string) (*Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed JWT", ErrInvalidToken)
+	}
+
+	var header tokenHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: header: %v", ErrInvalidToken, err)
+	}
+	if header.Alg != "RS256" {
+		return nil, fmt.Errorf("%w: unexpected algorithm %q", ErrInvalidToken, header.Alg)
+	}
+	if header.Kid == "" {
+		return nil, fmt.Errorf("%w: missing key ID", ErrInvalidToken)
+	}
+
+	key, err := v.keys.Key(ctx, header.Kid)
+	if errors.Is(err, ErrUnknownKey) {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if err != nil {
+		return nil, err // Key source unavailable; not the caller's fault
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: signature: %v", ErrInvalidToken, err)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig); err != nil {
+		return nil, fmt.Errorf("%w: bad signature", ErrInvalidToken)
+	}
+
+	var claims tokenClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: claims: %v", ErrInvalidToken, err)
+	}
+	if err := v.checkClaims(&claims); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	var all map[string]any
+	if err := decodeSegment(parts[1], &all); err != nil {
+		return nil, fmt.Errorf("%w: claims: %v", ErrInvalidToken, err)
+	}
+	var firebase struct {
+		SignInProvider string `json:"sign_in_provider"`
+	}
+	if len(claims.Firebase) > 0 {
+		_ = json.Unmarshal(claims.Firebase, &firebase) // Informational only
+	}
+
+	return &Principal{
+		UID:            claims.Subject,
+		Email:          claims.Email,
+		EmailVerified:  claims.Verified,
+		SignInProvider: firebase.SignInProvider,
+		IssuedAt:       time.Unix(claims.IssuedAt, 0),
+		ExpiresAt:      time.Unix(claims.Expires, 0),
+		Claims:         all,
+	}, nil
+}
+
+func (v *Verifier) checkClaims(c *tokenClaims) error {
+	now := v.now()
+	switch {
+	case c.Audience != v.projectID:
+		return fmt.Errorf("audience %q does not match project %q", c.Audience, v.projectID)
+	case c.Issuer != "https://securetoken.google.com/"+v.projectID:
+		return fmt.Errorf("unexpected issuer %q", c.Issuer)
+	case c.Subject == "" || len(c.Subject) > 128:
+		return errors.New("subject must be a non-empty string of at most 128 characters")
+	case c.Expires == 0 || !now.Before(time.Unix(c.Expires, 0).Add(v.leeway)):
+		return errors.New("token has expired")
+	case c.IssuedAt == 0 || time.Unix(c.IssuedAt, 0).After(now.Add(v.leeway)):
+		return errors.New("token issued in the future")
+	case c.AuthTime == 0 || time.Unix(c.AuthTime, 0).After(now.Add(v.leeway)):
+		return errors.New("authentication time is in the future")
+	}
+	return nil
+}
+
+func decodeSegment(seg string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
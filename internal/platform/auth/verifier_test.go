@@ -0,0 +1,168 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testProject = "test-project"
+
+var (
+	testKey  = mustKey()
+	otherKey = mustKey()
+	testNow  = time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+)
+
+func mustKey() *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+// writeJWKS writes the public halves of keys, by key ID, as a JWKS file.
+func writeJWKS(t *testing.T, keys map[string]*rsa.PrivateKey) string {
+	t.Helper()
+	type jwk struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+	}
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	for kid, k := range keys {
+		doc.Keys = append(doc.Keys, jwk{
+			Kty: "RSA",
+			Kid: kid,
+			N:   base64.RawURLEncoding.EncodeToString(k.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+		})
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "keys.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// sign builds an RS256 JWT with the given header fields and claims.
+func sign(t *testing.T, key *rsa.PrivateKey, header, claims map[string]any) string {
+	t.Helper()
+	segment := func(v any) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	signed := segment(header) + "." + segment(claims)
+	digest := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func validClaims() map[string]any {
+	return map[string]any{
+		"iss":            "https://securetoken.google.com/" + testProject,
+		"aud":            testProject,
+		"sub":            "uid-1",
+		"iat":            testNow.Add(-time.Minute).Unix(),
+		"exp":            testNow.Add(time.Hour).Unix(),
+		"auth_time":      testNow.Add(-time.Minute).Unix(),
+		"email":          "admin@example.com",
+		"email_verified": true,
+		"firebase":       map[string]any{"sign_in_provider": "password"},
+	}
+}
+
+func TestVerify(t *testing.T) {
+	path := writeJWKS(t, map[string]*rsa.PrivateKey{"k1": testKey})
+	v := NewVerifier(testProject, NewKeyCache(FileKeySource{Path: path}))
+	v.now = func() time.Time { return testNow }
+
+	header := map[string]any{"alg": "RS256", "kid": "k1"}
+	with := func(key string, value any) map[string]any {
+		c := validClaims()
+		if value == nil {
+			delete(c, key)
+		} else {
+			c[key] = value
+		}
+		return c
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		ok    bool
+	}{
+		{"valid", sign(t, testKey, header, validClaims()), true},
+		{"within leeway after expiry", sign(t, testKey, header, with("exp", testNow.Add(-10*time.Second).Unix())), true},
+		{"signed by another key", sign(t, otherKey, header, validClaims()), false},
+		{"expired", sign(t, testKey, header, with("exp", testNow.Add(-time.Hour).Unix())), false},
+		{"no expiry", sign(t, testKey, header, with("exp", nil)), false},
+		{"issued in the future", sign(t, testKey, header, with("iat", testNow.Add(time.Hour).Unix())), false},
+		{"authenticated in the future", sign(t, testKey, header, with("auth_time", testNow.Add(time.Hour).Unix())), false},
+		{"other audience", sign(t, testKey, header, with("aud", "other-project")), false},
+		{"other issuer", sign(t, testKey, header, with("iss", "https://securetoken.google.com/other-project")), false},
+		{"empty subject", sign(t, testKey, header, with("sub", "")), false},
+		{"unknown key ID", sign(t, testKey, map[string]any{"alg": "RS256", "kid": "k2"}, validClaims()), false},
+		{"missing key ID", sign(t, testKey, map[string]any{"alg": "RS256"}, validClaims()), false},
+		{"wrong algorithm", sign(t, testKey, map[string]any{"alg": "HS256", "kid": "k1"}, validClaims()), false},
+		{"malformed", "not.a-jwt", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := v.Verify(context.Background(), tt.token)
+			if !tt.ok {
+				if !errors.Is(err, ErrInvalidToken) {
+					t.Fatalf("got %v, want ErrInvalidToken", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.UID != "uid-1" || p.Email != "admin@example.com" || !p.EmailVerified || p.SignInProvider != "password" {
+				t.Errorf("principal %+v", p)
+			}
+		})
+	}
+}
+
+func TestVerifyTamperedClaims(t *testing.T) {
+	path := writeJWKS(t, map[string]*rsa.PrivateKey{"k1": testKey})
+	v := NewVerifier(testProject, NewKeyCache(FileKeySource{Path: path}))
+	v.now = func() time.Time { return testNow }
+
+	header := map[string]any{"alg": "RS256", "kid": "k1"}
+	genuine := strings.Split(sign(t, testKey, header, validClaims()), ".")
+	forged := strings.Split(sign(t, otherKey, header, map[string]any{"sub": "someone-else"}), ".")
+	// The genuine signature over forged claims.
+	tampered := genuine[0] + "." + forged[1] + "." + genuine[2]
+	if _, err := v.Verify(context.Background(), tampered); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("tampered token: got %v, want ErrInvalidToken", err)
+	}
+}
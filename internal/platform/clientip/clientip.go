@@ -0,0 +1,94 @@
+// Package clientip finds the address of the client behind a request. Behind
+// a reverse proxy the connection comes from the proxy, so the client is read
+// from X-Forwarded-For, but only from the entries that trusted proxies
+// appended: anything further left may have been sent by the client itself.
+package clientip
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// Header is the header proxies append the address they received a request
+// from to.
+const Header = "X-Forwarded-For"
+
+// Resolver resolves client addresses given the proxies it trusts.
+type Resolver struct {
+	trusted []netip.Prefix
+}
+
+// New returns a Resolver trusting the proxies in cidrs, each a CIDR range
+// such as 10.0.0.0/8 or a single address. With no proxies it always returns
+// the connection's peer address.
+func New(cidrs []string) (*Resolver, error) {
+	r := &Resolver{}
+	for _, s := range cidrs {
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(s)
+			if addrErr != nil {
+				return nil, fmt.Errorf("clientip: %q is not an address or CIDR range", s)
+			}
+			prefix = netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen())
+		}
+		r.trusted = append(r.trusted, prefix.Masked())
+	}
+	return r, nil
+}
+
+// IP returns the address of the client that sent req. If the peer is a
+// trusted proxy, X-Forwarded-For is walked from the right and the first
+// address that is not a trusted proxy is the client; if every entry is
+// trusted, the leftmost one is. An entry that does not parse ends the walk
+// at the proxy that forwarded it.
+func (r *Resolver) IP(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+	client, err := netip.ParseAddr(host)
+	if err != nil {
+		return host
+	}
+	client = client.Unmap()
+
+	var hops []string
+	for _, v := range req.Header.Values(Header) {
+		hops = append(hops, strings.Split(v, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0 && r.trusts(client); i-- {
+		hop, ok := parseHop(hops[i])
+		if !ok {
+			break
+		}
+		client = hop
+	}
+	return client.String()
+}
+
+func (r *Resolver) trusts(addr netip.Addr) bool {
+	for _, p := range r.trusted {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseHop parses one X-Forwarded-For entry. Some proxies include a port,
+// bracketing IPv6 addresses when they do.
+func parseHop(s string) (netip.Addr, bool) {
+	s = strings.TrimSpace(s)
+	if addrPort, err := netip.ParseAddrPort(s); err == nil {
+		return addrPort.Addr().Unmap(), true
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
@@ -0,0 +1,64 @@
+package clientip
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIP(t *testing.T) {
+	r, err := New([]string{"10.0.0.0/8", "2001:db8::1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name   string
+		remote string
+		xff    []string
+		want   string
+	}{
+		{"direct", "203.0.113.7:5000", nil, "203.0.113.7"},
+		{"untrusted peer ignores header", "203.0.113.7:5000", []string{"198.51.100.1"}, "203.0.113.7"},
+		{"trusted peer", "10.0.0.2:5000", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"spoofed left entries", "10.0.0.2:5000", []string{"1.2.3.4, 198.51.100.1"}, "198.51.100.1"},
+		{"trusted chain", "10.0.0.2:5000", []string{"198.51.100.1, 10.0.0.3"}, "198.51.100.1"},
+		{"repeated headers", "10.0.0.2:5000", []string{"198.51.100.1", "10.0.0.3"}, "198.51.100.1"},
+		{"all trusted", "10.0.0.2:5000", []string{"10.0.0.4, 10.0.0.3"}, "10.0.0.4"},
+		{"entry with port", "10.0.0.2:5000", []string{"198.51.100.1:4711"}, "198.51.100.1"},
+		{"bracketed IPv6 entry", "10.0.0.2:5000", []string{"[2001:db8::9]:4711"}, "2001:db8::9"},
+		{"garbage stops the walk", "10.0.0.2:5000", []string{"198.51.100.1, unknown"}, "10.0.0.2"},
+		{"IPv6 proxy", "[2001:db8::1]:443", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"IPv4-mapped peer", "[::ffff:10.0.0.2]:443", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"no port", "203.0.113.7", nil, "203.0.113.7"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tc.remote
+			for _, v := range tc.xff {
+				req.Header.Add(Header, v)
+			}
+			if got := r.IP(req); got != tc.want {
+				t.Errorf("IP = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	r, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.0.0.2:5000"
+	req.Header.Set(Header, "198.51.100.1")
+	if got := r.IP(req); got != "10.0.0.2" {
+		t.Errorf("with no proxies IP = %q, want the peer", got)
+	}
+
+	for _, bad := range []string{"proxy.internal", "10.0.0.0/33", ""} {
+		if _, err := New([]string{bad}); err == nil {
+			t.Errorf("New(%q) succeeded", bad)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+// Package cors lets pages served from other origins, such as the admin
+// dashboard's development server, call the API from a browser.
+//
+// Only configured origins are granted access. Preflight requests are
+// answered here, before authentication, since browsers send them without
+// credentials.
+package cors
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"seattle-info-platform/internal/platform/problem"
+)
+
+// CodePreflightRejected is the problem code of a refused preflight request.
+const CodePreflightRejected = "cors_preflight_rejected"
+
+// Options configures Middleware.
+type Options struct {
+	// AllowedOrigins lists origins such as http://localhost:3000, compared
+	// ignoring case. "*" allows every origin.
+	AllowedOrigins []string
+	AllowedMethods []string
+	// AllowedHeaders lists the request headers, beyond the CORS-safelisted
+	// ones, that cross-origin requests may send.
+	AllowedHeaders []string
+	// ExposedHeaders lists the response headers scripts may read.
+	ExposedHeaders []string
+	// AllowCredentials lets browsers send cookies and HTTP authentication.
+	// Bearer tokens set by the page need only AllowedHeaders.
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache a preflight response.
+	MaxAge time.Duration
+}
+
+// Middleware adds CORS headers to responses to requests from allowed
+// origins and answers their preflight requests with 204 No Content;
+// preflights asking for a method or header that is not allowed get 403.
+// Requests from other origins are passed on untouched, so the browser
+// withholds the response from the page. With no allowed origins it does
+// nothing.
+func Middleware(o Options) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if len(o.AllowedOrigins) == 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			h.Add("Vary", "Origin")
+			origin := r.Header.Get("Origin")
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+			if preflight {
+				h.Add("Vary", "Access-Control-Request-Method")
+				h.Add("Vary", "Access-Control-Request-Headers")
+			}
+			if origin == "" || !o.allowsOrigin(origin) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h.Set("Access-Control-Allow-Origin", origin)
+			if o.AllowCredentials {
+				h.Set("Access-Control-Allow-Credentials", "true")
+			}
+			if !preflight {
+				if len(o.ExposedHeaders) > 0 {
+					h.Set("Access-Control-Expose-Headers", strings.Join(o.ExposedHeaders, ", "))
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if method := r.Header.Get("Access-Control-Request-Method"); !slices.Contains(o.AllowedMethods, method) {
+				problem.Error(w, r, http.StatusForbidden, CodePreflightRejected,
+					"Method "+method+" is not allowed for cross-origin requests")
+				return
+			}
+			for _, name := range strings.Split(r.Header.Get("Access-Control-Request-Headers"), ",") {
+				if name = strings.TrimSpace(name); name != "" && !containsFold(o.AllowedHeaders, name) {
+					problem.Error(w, r, http.StatusForbidden, CodePreflightRejected,
+						"Header "+name+" is not allowed for cross-origin requests")
+					return
+				}
+			}
+			h.Set("Access-Control-Allow-Methods", strings.Join(o.AllowedMethods, ", "))
+			if len(o.AllowedHeaders) > 0 {
+				h.Set("Access-Control-Allow-Headers", strings.Join(o.AllowedHeaders, ", "))
+			}
+			if o.MaxAge > 0 {
+				h.Set("Access-Control-Max-Age", strconv.Itoa(int(o.MaxAge.Seconds())))
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
+
+func (o Options) allowsOrigin(origin string) bool {
+	return slices.Contains(o.AllowedOrigins, "*") || containsFold(o.AllowedOrigins, origin)
+}
+
+func containsFold(list []string, s string) bool {
+	return slices.ContainsFunc(list, func(v string) bool { return strings.EqualFold(v, s) })
+}
@@ -0,0 +1,125 @@
+package cors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var options = Options{
+	AllowedOrigins:   []string{"https://admin.example.com"},
+	AllowedMethods:   []string{http.MethodGet, http.MethodPatch},
+	AllowedHeaders:   []string{"Authorization", "Content-Type"},
+	ExposedHeaders:   []string{"X-Request-ID"},
+	AllowCredentials: true,
+	MaxAge:           10 * time.Minute,
+}
+
+func serve(o Options, method, origin string, header map[string]string) *httptest.ResponseRecorder {
+	h := Middleware(o)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	r := httptest.NewRequest(method, "/api/v1/users", nil)
+	if origin != "" {
+		r.Header.Set("Origin", origin)
+	}
+	for k, v := range header {
+		r.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestPreflight(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		origin  string
+		method  string
+		headers string
+		status  int
+		allowed bool
+	}{
+		{"allowed", "https://admin.example.com", "PATCH", "authorization, content-type", http.StatusNoContent, true},
+		{"origin case", "HTTPS://Admin.Example.com", "GET", "", http.StatusNoContent, true},
+		{"method not allowed", "https://admin.example.com", "DELETE", "", http.StatusForbidden, true},
+		{"header not allowed", "https://admin.example.com", "GET", "X-Debug", http.StatusForbidden, true},
+		{"other origin", "https://evil.example", "PATCH", "", http.StatusTeapot, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := serve(options, http.MethodOptions, tc.origin, map[string]string{
+				"Access-Control-Request-Method":  tc.method,
+				"Access-Control-Request-Headers": tc.headers,
+			})
+			if w.Code != tc.status {
+				t.Errorf("status = %d, want %d", w.Code, tc.status)
+			}
+			h := w.Header()
+			if got := h.Get("Access-Control-Allow-Origin"); (got == tc.origin) != tc.allowed {
+				t.Errorf("Access-Control-Allow-Origin = %q, allowed %v", got, tc.allowed)
+			}
+			if tc.status != http.StatusNoContent {
+				return
+			}
+			for name, want := range map[string]string{
+				"Access-Control-Allow-Methods":     "GET, PATCH",
+				"Access-Control-Allow-Headers":     "Authorization, Content-Type",
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Max-Age":           "600",
+			} {
+				if got := h.Get(name); got != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+			if vary := h.Values("Vary"); len(vary) != 3 {
+				t.Errorf("Vary = %q, want Origin and the request headers", vary)
+			}
+		})
+	}
+}
+
+func TestActualRequest(t *testing.T) {
+	w := serve(options, http.MethodGet, "https://admin.example.com", nil)
+	h := w.Header()
+	if w.Code != http.StatusTeapot {
+		t.Errorf("status = %d, want the handler's", w.Code)
+	}
+	if h.Get("Access-Control-Allow-Origin") != "https://admin.example.com" ||
+		h.Get("Access-Control-Expose-Headers") != "X-Request-ID" ||
+		h.Get("Access-Control-Allow-Methods") != "" {
+		t.Errorf("headers = %v", h)
+	}
+
+	w = serve(options, http.MethodGet, "https://evil.example", nil)
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("other origin granted %q", got)
+	}
+	if got := w.Header().Get("Vary"); got != "Origin" {
+		t.Errorf("Vary = %q, want Origin so caches keep responses apart", got)
+	}
+
+	// An OPTIONS request without Access-Control-Request-Method is not a
+	// preflight and reaches the handler.
+	if w := serve(options, http.MethodOptions, "https://admin.example.com", nil); w.Code != http.StatusTeapot {
+		t.Errorf("plain OPTIONS status = %d, want the handler's", w.Code)
+	}
+}
+
+func TestWildcardAndDisabled(t *testing.T) {
+	open := Options{AllowedOrigins: []string{"*"}, AllowedMethods: []string{http.MethodGet}}
+	w := serve(open, http.MethodGet, "https://anyone.example", nil)
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "https://anyone.example" {
+		t.Errorf("wildcard Access-Control-Allow-Origin = %q", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Errorf("Access-Control-Allow-Credentials = %q without AllowCredentials", got)
+	}
+
+	w = serve(Options{}, http.MethodOptions, "https://admin.example.com", map[string]string{
+		"Access-Control-Request-Method": "GET",
+	})
+	if w.Code != http.StatusTeapot || len(w.Header()) != 0 {
+		t.Errorf("disabled middleware = %d %v, want the handler untouched", w.Code, w.Header())
+	}
+}
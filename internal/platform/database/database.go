@@ -1,3 +1,181 @@
+// Package database provides storage for users, listings and categories behind
+// repository interfaces, so handlers never touch the underlying storage
+// directly. Two implementations are available: an in-memory store (used by
+// tests and for throwaway local runs) and a file-backed store that persists
+// every write to disk.
 package database
 
-// TODO: Implement database related logic
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"seattle-info-platform/internal/category"
+	"seattle-info-platform/internal/listing"
+	"seattle-info-platform/internal/platform/search"
+	"seattle-info-platform/internal/user"
+)
+
+var (
+	// ErrNotFound is returned when a record with the requested ID does not exist.
+	ErrNotFound = errors.New("database: record not found")
+	// ErrDuplicateID is returned when creating a record whose ID is already taken.
+	ErrDuplicateID = errors.New("database: duplicate id")
+	// ErrLocked is returned when another process, such as a running server
+	// or a migration, has the database file open.
+	ErrLocked = errors.New("database: file is locked by another process")
+	// ErrCategoryInUse is returned when deleting a category that listings
+	// still reference without naming a category to move them to.
+	ErrCategoryInUse = errors.New("database: category has listings")
+	// ErrInvalidReassignment is returned when the category listings should be
+	// moved to does not exist or is the category being deleted.
+	ErrInvalidReassignment = errors.New("database: invalid reassignment category")
+)
+
+// UserFilter narrows the result of UserRepository.List. Zero-valued fields
+// are ignored; a user must match all the others.
+type UserFilter struct {
+	Status user.UserStatus
+	Role   user.UserRole
+	// Query holds whitespace-separated terms. Each must occur, ignoring
+	// case, in the user's email, first name or last name.
+	Query string
+	// RegisteredAfter and RegisteredBefore bound RegistrationDate; the lower
+	// bound is inclusive and the upper one exclusive.
+	RegisteredAfter  time.Time
+	RegisteredBefore time.Time
+	AuthProvider     string
+	EmailVerified    *bool
+}
+
+// Matches reports whether u satisfies every condition of f.
+func (f UserFilter) Matches(u *user.User) bool {
+	switch {
+	case f.Status != "" && u.Status != f.Status,
+		f.Role != "" && u.Role != f.Role,
+		f.AuthProvider != "" && u.AuthProvider != f.AuthProvider,
+		f.EmailVerified != nil && u.IsEmailVerified != *f.EmailVerified,
+		!f.RegisteredAfter.IsZero() && u.RegistrationDate.Before(f.RegisteredAfter),
+		!f.RegisteredBefore.IsZero() && !u.RegistrationDate.Before(f.RegisteredBefore):
+		return false
+	}
+	text := searchText(u)
+	for _, term := range f.terms() {
+		if !strings.Contains(text, term) {
+			return false
+		}
+	}
+	return true
+}
+
+func (f UserFilter) terms() []string {
+	return strings.Fields(strings.ToLower(f.Query))
+}
+
+// searchText is the text UserFilter.Query searches. Fields are separated by
+// a NUL byte so a term cannot match across two of them.
+func searchText(u *user.User) string {
+	return strings.ToLower(u.Email + "\x00" + u.FirstName + "\x00" + u.LastName)
+}
+
+// UserRepository stores user accounts.
+type UserRepository interface {
+	// List returns the users matching filter in no particular order.
+	List(ctx context.Context, filter UserFilter) ([]user.User, error)
+	Get(ctx context.Context, id string) (*user.User, error)
+	// CountByStatus returns how many users are in each status. Like
+	// CategoryRepository.ListingCounts it reads maintained counts rather
+	// than scanning.
+	CountByStatus(ctx context.Context) (map[user.UserStatus]int, error)
+	// GetByEmail finds a user by email address, ignoring case.
+	GetByEmail(ctx context.Context, email string) (*user.User, error)
+	Create(ctx context.Context, u *user.User) error
+	Update(ctx context.Context, u *user.User) error
+	// UpdateFunc atomically loads the user, applies fn and stores the result.
+	// No other write can interleave; an error from fn aborts the update and
+	// is returned unchanged.
+	//
+	// Update and UpdateFunc refuse with user.ErrLastAdmin a change that
+	// leaves no active admin where there was one.
+	UpdateFunc(ctx context.Context, id string, fn func(*user.User) error) (*user.User, error)
+}
+
+// ListingFilter narrows the result of ListingRepository.List. Zero-valued
+// fields are ignored.
+type ListingFilter struct {
+	Status     listing.ListingStatus
+	CategoryID string
+	// IncludeDescendants widens CategoryID to every category nested below it.
+	IncludeDescendants bool
+}
+
+// ListingMatch is a listing found by ListingRepository.Search.
+type ListingMatch struct {
+	listing.Listing
+	// Score ranks the match; higher is more relevant.
+	Score float64 `json:"score"`
+	// Highlights holds HTML snippets of the matching fields ("title",
+	// "description") with the matched words in <mark> tags.
+	Highlights map[string]string `json:"highlights,omitempty"`
+}
+
+// ListingRepository stores listings.
+type ListingRepository interface {
+	List(ctx context.Context, filter ListingFilter) ([]listing.Listing, error)
+	// Search finds the listings matching filter whose title or description
+	// match query, most relevant first. The index is kept in step with every
+	// write, so results are never stale.
+	Search(ctx context.Context, query *search.Query, filter ListingFilter) ([]ListingMatch, error)
+	Get(ctx context.Context, id string) (*listing.Listing, error)
+	// CountByStatus returns how many listings are in each status, from
+	// maintained counts.
+	CountByStatus(ctx context.Context) (map[listing.ListingStatus]int, error)
+	Create(ctx context.Context, l *listing.Listing) error
+	Update(ctx context.Context, l *listing.Listing) error
+	// UpdateFunc atomically loads the listing, applies fn and stores the
+	// result. No other write can interleave; an error from fn aborts the
+	// update and is returned unchanged.
+	UpdateFunc(ctx context.Context, id string, fn func(*listing.Listing) error) (*listing.Listing, error)
+}
+
+// CategoryRepository stores listing categories. Create and UpdateFunc reject
+// changes that break the rules of category.CheckHierarchy or
+// category.CheckSlug.
+type CategoryRepository interface {
+	List(ctx context.Context) ([]category.Category, error)
+	Get(ctx context.Context, id string) (*category.Category, error)
+	// Create stores c. commit, if not nil, is called in the same atomic
+	// write once c is known to be valid; its error aborts the write.
+	Create(ctx context.Context, c *category.Category, commit func() error) error
+	// ListingCounts returns the listing counts of every category, keyed by
+	// category ID. It does not scan listings; stores keep the counts up to
+	// date as listings change.
+	ListingCounts(ctx context.Context) (map[string]category.ListingCounts, error)
+	// UpdateFunc atomically loads the category, applies fn and stores the
+	// result. No other write can interleave; an error from fn aborts the
+	// update and is returned unchanged.
+	UpdateFunc(ctx context.Context, id string, fn func(*category.Category) error) (*category.Category, error)
+	// Delete removes the category. Listings in it are moved to reassignTo in
+	// the same atomic write and their number is returned. If reassignTo is
+	// empty and the category has listings, Delete fails with
+	// ErrCategoryInUse and changes nothing. Subcategories move up to the
+	// deleted category's parent. Moved listings and subcategories get a new
+	// UpdatedAt. commit, if not nil, is called with the number of moved
+	// listings in the same atomic write; its error aborts the delete.
+	Delete(ctx context.Context, id, reassignTo string, commit func(reassigned int) error) (reassigned int, err error)
+}
+
+// Store groups the repositories of one storage backend. Implementations must
+// be safe for concurrent use by multiple goroutines.
+type Store interface {
+	Users() UserRepository
+	Listings() ListingRepository
+	Categories() CategoryRepository
+	// Ping reports whether the store can serve requests: whether its lock
+	// can be taken before ctx is done and, for a file-backed store, whether
+	// the file is still there.
+	Ping(ctx context.Context) error
+	// Close flushes pending state and releases the backend's resources.
+	Close() error
+}
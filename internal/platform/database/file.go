@@ -0,0 +1,296 @@
+package database
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"seattle-info-platform/internal/category"
+	"seattle-info-platform/internal/listing"
+	"seattle-info-platform/internal/user"
+)
+
+// document is the on-disk representation of the database: the migration
+// history plus one JSON array of rows per table.
+type document struct {
+	Migrations []AppliedMigration         `json:"schema_migrations"`
+	Tables     map[string]json.RawMessage `json:"tables"`
+}
+
+// readDocument loads the database file at path. A missing file yields an
+// empty document with no tables and no applied migrations.
+func readDocument(path string) (*document, error) {
+	doc := &document{Tables: make(map[string]json.RawMessage)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return doc, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("database: read %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, doc); err != nil {
+		return nil, fmt.Errorf("database: decode %s: %w", path, err)
+	}
+	if doc.Tables == nil {
+		doc.Tables = make(map[string]json.RawMessage)
+	}
+	return doc, nil
+}
+
+// write atomically replaces the file at path with the document. The data is
+// written to a temporary file and renamed into place, so a crash never leaves
+// a half-written database behind.
+func (d *document) write(path string) error {
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return fmt.Errorf("database: encode document: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("database: create directory for %s: %w", path, err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("database: create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // no-op once the rename succeeded
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("database: write document: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("database: sync document: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("database: close document: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("database: replace %s: %w", path, err)
+	}
+	return nil
+}
+
+// rows decodes every table into generic rows for schema operations.
+func (d *document) rows() (map[string][]row, error) {
+	tables := make(map[string][]row, len(d.Tables))
+	for name, data := range d.Tables {
+		var rows []row
+		if err := json.Unmarshal(data, &rows); err != nil {
+			return nil, fmt.Errorf("decode table %q: %w", name, err)
+		}
+		if rows == nil {
+			rows = []row{}
+		}
+		tables[name] = rows
+	}
+	return tables, nil
+}
+
+// setRows replaces every table with the given generic rows.
+func (d *document) setRows(tables map[string][]row) error {
+	encoded := make(map[string]json.RawMessage, len(tables))
+	for name, rows := range tables {
+		data, err := json.Marshal(rows)
+		if err != nil {
+			return fmt.Errorf("encode table %q: %w", name, err)
+		}
+		encoded[name] = data
+	}
+	d.Tables = encoded
+	return nil
+}
+
+// decodeTable decodes the named table into typed records.
+func decodeTable[T any](d *document, name string) ([]T, error) {
+	data, ok := d.Tables[name]
+	if !ok {
+		return nil, fmt.Errorf("table %q does not exist", name)
+	}
+	var out []T
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("decode table %q: %w", name, err)
+	}
+	return out, nil
+}
+
+// encodeTable stores typed records as the named table.
+func encodeTable[T any](d *document, name string, records []T) error {
+	if records == nil {
+		records = []T{}
+	}
+	data, err := json.Marshal(records)
+	if err != nil {
+		return fmt.Errorf("encode table %q: %w", name, err)
+	}
+	d.Tables[name] = data
+	return nil
+}
+
+// FileStore is a Store that serves reads from memory and writes the whole
+// database to a single file after every mutation.
+//
+// It holds an exclusive lock on path+".lock" while open, as the Migrator
+// does while it runs, so a migration never races a server that would
+// overwrite the migrated file with its own snapshot.
+type FileStore struct {
+	*MemoryStore
+	path string
+	doc  *document
+	lock *os.File
+}
+
+// OpenFile opens the file-backed store at path. The schema must be fully
+// migrated (see Migrator); a dirty, outdated or modified schema is refused.
+// It fails with ErrLocked if another process has the file open.
+func OpenFile(path string) (*FileStore, error) {
+	lock, err := lockFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s, err := openFile(path, lock)
+	if err != nil {
+		lock.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func openFile(path string, lock *os.File) (*FileStore, error) {
+	doc, err := readDocument(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyApplied(doc.Migrations, Migrations); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	s := &FileStore{MemoryStore: NewMemoryStore(), path: path, doc: doc, lock: lock}
+	if err := s.load(); err != nil {
+		return nil, fmt.Errorf("database: load %s: %w", path, err)
+	}
+	s.afterWrite = s.save
+	return s, nil
+}
+
+func (s *FileStore) load() error {
+	users, err := decodeTable[user.User](s.doc, "users")
+	if err != nil {
+		return err
+	}
+	listings, err := decodeTable[listing.Listing](s.doc, "listings")
+	if err != nil {
+		return err
+	}
+	categories, err := decodeTable[category.Category](s.doc, "categories")
+	if err != nil {
+		return err
+	}
+
+	for i := range users {
+		if _, err := s.users.insert(&users[i]); err != nil {
+			return fmt.Errorf("user %q: %w", users[i].ID, err)
+		}
+	}
+	for i := range listings {
+		if _, err := s.listings.insert(&listings[i]); err != nil {
+			return fmt.Errorf("listing %q: %w", listings[i].ID, err)
+		}
+	}
+	for i := range categories {
+		if _, err := s.categories.insert(&categories[i]); err != nil {
+			return fmt.Errorf("category %q: %w", categories[i].ID, err)
+		}
+	}
+	return nil
+}
+
+// save writes the current contents of every table to disk. The caller must
+// hold the store's lock.
+func (s *FileStore) save() error {
+	if err := encodeTable(s.doc, "users", s.users.all()); err != nil {
+		return fmt.Errorf("database: %w", err)
+	}
+	if err := encodeTable(s.doc, "listings", s.listings.all()); err != nil {
+		return fmt.Errorf("database: %w", err)
+	}
+	if err := encodeTable(s.doc, "categories", s.categories.all()); err != nil {
+		return fmt.Errorf("database: %w", err)
+	}
+	return s.doc.write(s.path)
+}
+
+// Ping checks the lock like MemoryStore.Ping and that the database file
+// still exists.
+func (s *FileStore) Ping(ctx context.Context) error {
+	if err := s.MemoryStore.Ping(ctx); err != nil {
+		return err
+	}
+	if _, err := os.Stat(s.path); err != nil {
+		return fmt.Errorf("database: %w", err)
+	}
+	return nil
+}
+
+// CheckSchema rereads the migration history from the database file and
+// fails if it is no longer fully migrated, for instance after the file was
+// replaced or edited by hand. Only the head of the file is read.
+func (s *FileStore) CheckSchema() error {
+	applied, err := readMigrations(s.path)
+	if err != nil {
+		return err
+	}
+	return verifyApplied(applied, Migrations)
+}
+
+// readMigrations decodes the migration history of the database file at
+// path. document.write puts it first, so the tables are normally skipped.
+func readMigrations(path string) ([]AppliedMigration, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("database: %w", err)
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(bufio.NewReader(f))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, fmt.Errorf("database: decode %s: not a JSON object", path)
+	}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("database: decode %s: %w", path, err)
+		}
+		var value json.RawMessage
+		var applied []AppliedMigration
+		target := any(&value)
+		if key == "schema_migrations" {
+			target = &applied
+		}
+		if err := dec.Decode(target); err != nil {
+			return nil, fmt.Errorf("database: decode %s: %w", path, err)
+		}
+		if key == "schema_migrations" {
+			return applied, nil
+		}
+	}
+	return nil, nil
+}
+
+// Close writes a final snapshot and releases the file lock.
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := s.save()
+	if s.lock != nil {
+		err = errors.Join(err, s.lock.Close())
+		s.lock = nil
+	}
+	return err
+}
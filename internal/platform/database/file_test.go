@@ -0,0 +1,156 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"seattle-info-platform/internal/category"
+	"seattle-info-platform/internal/listing"
+	"seattle-info-platform/internal/user"
+)
+
+// openMigrated opens a fully migrated file store in a temporary directory.
+func openMigrated(t *testing.T) (*FileStore, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "db.json")
+	m, err := NewMigrator(path, Migrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+	s, err := OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, path
+}
+
+func TestFileStoreRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	s, path := openMigrated(t)
+	now := time.Now().UTC().Truncate(time.Second)
+	if err := s.Users().Create(ctx, &user.User{ID: "u1", Email: "ann@example.com", Role: user.RoleAdmin, Status: user.StatusActive, CreatedAt: now}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Categories().Create(ctx, &category.Category{ID: "c1", Name: "Jobs", Slug: "jobs"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Listings().Create(ctx, &listing.Listing{ID: "l1", Title: "Bike", Status: listing.StatusActive, CategoryID: "c1"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	u, err := s.Users().Get(ctx, "u1")
+	if err != nil || u.Email != "ann@example.com" || u.Role != user.RoleAdmin || !u.CreatedAt.Equal(now) {
+		t.Errorf("reloaded user = %+v, %v", u, err)
+	}
+	if c, err := s.Categories().Get(ctx, "c1"); err != nil || c.Slug != "jobs" {
+		t.Errorf("reloaded category = %+v, %v", c, err)
+	}
+	// Derived data is rebuilt on load.
+	counts, err := s.Categories().ListingCounts(ctx)
+	if err != nil || counts["c1"].Own[listing.StatusActive] != 1 {
+		t.Errorf("reloaded counts = %+v, %v", counts, err)
+	}
+}
+
+func TestFileStoreSaveFailureRollsBack(t *testing.T) {
+	ctx := context.Background()
+	s, path := openMigrated(t)
+	defer func() {
+		s.path = path
+		s.Close()
+	}()
+	if err := s.Users().Create(ctx, &user.User{ID: "u1", Status: user.StatusPendingApproval}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A path below a regular file cannot be written.
+	s.path = filepath.Join(path, "db.json")
+	if err := s.Users().Create(ctx, &user.User{ID: "u2"}); err == nil {
+		t.Fatal("Create succeeded although the file cannot be written")
+	}
+	if _, err := s.Users().UpdateFunc(ctx, "u1", func(u *user.User) error {
+		u.Status = user.StatusActive
+		return nil
+	}); err == nil {
+		t.Fatal("UpdateFunc succeeded although the file cannot be written")
+	}
+
+	if _, err := s.Users().Get(ctx, "u2"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("failed Create left u2 behind: %v", err)
+	}
+	if u, _ := s.Users().Get(ctx, "u1"); u.Status != user.StatusPendingApproval {
+		t.Errorf("failed UpdateFunc left status %q", u.Status)
+	}
+	if counts, _ := s.Users().CountByStatus(ctx); counts[user.StatusActive] != 0 || counts[user.StatusPendingApproval] != 1 {
+		t.Errorf("failed writes left counts %v", counts)
+	}
+}
+
+func TestFileStoreLock(t *testing.T) {
+	s, path := openMigrated(t)
+
+	if _, err := OpenFile(path); !errors.Is(err, ErrLocked) {
+		t.Errorf("second OpenFile = %v, want ErrLocked", err)
+	}
+	m, err := NewMigrator(path, Migrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Down(); !errors.Is(err, ErrLocked) {
+		t.Errorf("Down while open = %v, want ErrLocked", err)
+	}
+	if _, err := m.Up(); !errors.Is(err, ErrLocked) {
+		t.Errorf("Up while open = %v, want ErrLocked", err)
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Down(); err != nil {
+		t.Errorf("Down after Close: %v", err)
+	}
+}
+
+func TestMemoryStorePing(t *testing.T) {
+	s := NewMemoryStore()
+	if err := s.Ping(context.Background()); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+
+	s.mu.Lock()
+	ctx, cancel := context.WithTimeout(context.Background(), 3*pingInterval)
+	defer cancel()
+	if err := s.Ping(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Ping with the lock held = %v, want DeadlineExceeded", err)
+	}
+	s.mu.Unlock()
+	if err := s.Ping(context.Background()); err != nil {
+		t.Errorf("Ping after unlock: %v", err)
+	}
+}
+
+func TestFileStorePingMissingFile(t *testing.T) {
+	s, path := openMigrated(t)
+	defer s.Close()
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Ping(context.Background()); err == nil {
+		t.Error("Ping succeeded without the database file")
+	}
+}
@@ -0,0 +1,136 @@
+package database
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// idSet is a set of record IDs.
+type idSet map[string]struct{}
+
+// setIndex maps a key to the IDs of the records that have it.
+type setIndex[K comparable] map[K]idSet
+
+func (x setIndex[K]) add(k K, id string) {
+	ids := x[k]
+	if ids == nil {
+		ids = make(idSet)
+		x[k] = ids
+	}
+	ids[id] = struct{}{}
+}
+
+func (x setIndex[K]) remove(k K, id string) {
+	if ids := x[k]; ids != nil {
+		delete(ids, id)
+		if len(ids) == 0 {
+			delete(x, k)
+		}
+	}
+}
+
+// timeIndex keeps record IDs ordered by a timestamp for range scans.
+type timeIndex []timeEntry
+
+type timeEntry struct {
+	at time.Time
+	id string
+}
+
+func (e timeEntry) compare(o timeEntry) int {
+	if c := e.at.Compare(o.at); c != 0 {
+		return c
+	}
+	return strings.Compare(e.id, o.id)
+}
+
+func (x *timeIndex) add(at time.Time, id string) {
+	e := timeEntry{at, id}
+	i, _ := slices.BinarySearchFunc(*x, e, timeEntry.compare)
+	*x = slices.Insert(*x, i, e)
+}
+
+func (x *timeIndex) remove(at time.Time, id string) {
+	if i, ok := slices.BinarySearchFunc(*x, timeEntry{at, id}, timeEntry.compare); ok {
+		*x = slices.Delete(*x, i, i+1)
+	}
+}
+
+// between returns the IDs with from <= t < to; a zero bound is open.
+func (x timeIndex) between(from, to time.Time) idSet {
+	start := 0
+	if !from.IsZero() {
+		start, _ = slices.BinarySearchFunc(x, from, func(e timeEntry, t time.Time) int { return e.at.Compare(t) })
+	}
+	out := make(idSet)
+	for _, e := range x[start:] {
+		if !to.IsZero() && !e.at.Before(to) {
+			break
+		}
+		out[e.id] = struct{}{}
+	}
+	return out
+}
+
+// trigrams returns the distinct three-byte substrings of s, which must
+// already be lowercased.
+func trigrams(s string) []string {
+	var out []string
+	for i := 0; i+3 <= len(s); i++ {
+		out = append(out, s[i:i+3])
+	}
+	slices.Sort(out)
+	return slices.Compact(out)
+}
+
+// trigramIndex finds records whose text contains a fragment. Lookups return
+// candidates only; callers must confirm the match.
+type trigramIndex struct {
+	postings setIndex[string]
+}
+
+func (x *trigramIndex) add(text, id string) {
+	for _, g := range trigrams(text) {
+		x.postings.add(g, id)
+	}
+}
+
+func (x *trigramIndex) remove(text, id string) {
+	for _, g := range trigrams(text) {
+		x.postings.remove(g, id)
+	}
+}
+
+// candidates returns the IDs that may contain fragment, or ok == false if
+// fragment is too short to narrow the search.
+func (x *trigramIndex) candidates(fragment string) (ids idSet, ok bool) {
+	grams := trigrams(fragment)
+	if len(grams) == 0 {
+		return nil, false
+	}
+	sets := make([]idSet, 0, len(grams))
+	for _, g := range grams {
+		sets = append(sets, x.postings[g])
+	}
+	return intersect(sets...), true
+}
+
+// intersect returns the IDs present in every set. A nil set is empty.
+func intersect(sets ...idSet) idSet {
+	if len(sets) == 0 {
+		return nil
+	}
+	slices.SortFunc(sets, func(a, b idSet) int { return len(a) - len(b) })
+	out := make(idSet, len(sets[0]))
+next:
+	for id := range sets[0] {
+		for _, s := range sets[1:] {
+			if _, ok := s[id]; !ok {
+				continue next
+			}
+		}
+		out[id] = struct{}{}
+	}
+	return out
+}
@@ -0,0 +1,98 @@
+package database
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func ids(s idSet) []string {
+	var out []string
+	for id := range s {
+		out = append(out, id)
+	}
+	slices.Sort(out)
+	return out
+}
+
+func TestSetIndex(t *testing.T) {
+	x := make(setIndex[string])
+	x.add("a", "1")
+	x.add("a", "2")
+	x.add("b", "1")
+	x.remove("a", "1")
+	x.remove("b", "1")
+	x.remove("missing", "1")
+	if got := ids(x["a"]); !slices.Equal(got, []string{"2"}) {
+		t.Errorf("a = %q, want [2]", got)
+	}
+	if _, ok := x["b"]; ok {
+		t.Error("empty key b was kept")
+	}
+}
+
+func TestTimeIndexBetween(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	var x timeIndex
+	for i, d := range []int{5, 1, 3, 3, 9} {
+		x.add(day(d), string(rune('a'+i)))
+	}
+	x.remove(day(9), "e")
+	x.remove(day(9), "nope")
+
+	for _, tc := range []struct {
+		name     string
+		from, to time.Time
+		want     []string
+	}{
+		{"open", time.Time{}, time.Time{}, []string{"a", "b", "c", "d"}},
+		{"from is inclusive", day(3), time.Time{}, []string{"a", "c", "d"}},
+		{"to is exclusive", time.Time{}, day(5), []string{"b", "c", "d"}},
+		{"both", day(2), day(4), []string{"c", "d"}},
+		{"empty", day(6), day(8), nil},
+	} {
+		if got := ids(x.between(tc.from, tc.to)); !slices.Equal(got, tc.want) {
+			t.Errorf("%s: between = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestTrigramIndex(t *testing.T) {
+	x := trigramIndex{postings: make(setIndex[string])}
+	x.add("ann@example.com", "1")
+	x.add("bob@example.org", "2")
+	x.add("annabel", "3")
+
+	for _, tc := range []struct {
+		fragment string
+		want     []string
+		ok       bool
+	}{
+		{"ann", []string{"1", "3"}, true},
+		{"example", []string{"1", "2"}, true},
+		{"ple.org", []string{"2"}, true},
+		{"zzz", nil, true},
+		{"an", nil, false}, // too short to narrow
+	} {
+		got, ok := x.candidates(tc.fragment)
+		if ok != tc.ok || !slices.Equal(ids(got), tc.want) {
+			t.Errorf("candidates(%q) = %q, %v; want %q, %v", tc.fragment, ids(got), ok, tc.want, tc.ok)
+		}
+	}
+
+	x.remove("annabel", "3")
+	if got, _ := x.candidates("ann"); !slices.Equal(ids(got), []string{"1"}) {
+		t.Errorf("candidates after remove = %q", ids(got))
+	}
+}
+
+func TestIntersect(t *testing.T) {
+	a := idSet{"1": {}, "2": {}, "3": {}}
+	b := idSet{"2": {}, "3": {}}
+	if got := ids(intersect(a, b, idSet{"3": {}, "4": {}})); !slices.Equal(got, []string{"3"}) {
+		t.Errorf("intersect = %q, want [3]", got)
+	}
+	if got := intersect(a, nil); len(got) != 0 {
+		t.Errorf("intersect with nil = %q, want empty", ids(got))
+	}
+}
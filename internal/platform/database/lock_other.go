@@ -0,0 +1,23 @@
+//go:build !unix
+
+package database
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// lockFile creates the lock file of the database at path. Without flock the
+// file is not locked, so this platform does not stop a migration from
+// running against a database a server has open.
+func lockFile(path string) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("database: create directory for %s: %w", path, err)
+	}
+	f, err := os.OpenFile(path+".lock", os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("database: open lock file: %w", err)
+	}
+	return f, nil
+}
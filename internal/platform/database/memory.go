@@ -0,0 +1,568 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"seattle-info-platform/internal/category"
+	"seattle-info-platform/internal/listing"
+	"seattle-info-platform/internal/platform/search"
+	"seattle-info-platform/internal/user"
+)
+
+// errIDChanged is returned when an UpdateFunc callback modifies the record ID.
+var errIDChanged = errors.New("database: update must not change the record id")
+
+// table is an insertion-ordered collection of records keyed by ID. It does
+// no locking of its own; MemoryStore guards every table with one mutex.
+type table[T any] struct {
+	rows  map[string]T
+	order []string
+	id    func(*T) string
+	// clone, when set, deep-copies the slices of a row in place. Rows are
+	// copied in and out of the table so callers never share them with the
+	// stored version.
+	clone func(*T)
+
+	// observe, when set, is told about every change, including undos, so
+	// derived data can be kept in step. old is nil for an insert and new is
+	// nil for a removal.
+	observe func(old, new *T)
+}
+
+func (t *table[T]) notify(old, new *T) {
+	if t.observe != nil {
+		t.observe(old, new)
+	}
+}
+
+func newTable[T any](id func(*T) string) *table[T] {
+	return &table[T]{rows: make(map[string]T), id: id}
+}
+
+// copy returns a copy of row that shares no memory with it.
+func (t *table[T]) copy(row T) T {
+	if t.clone != nil {
+		t.clone(&row)
+	}
+	return row
+}
+
+func (t *table[T]) get(id string) (*T, error) {
+	row, ok := t.rows[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	row = t.copy(row)
+	return &row, nil
+}
+
+// insert adds row and returns a function that removes it again.
+func (t *table[T]) insert(row *T) (undo func(), err error) {
+	id := t.id(row)
+	if _, ok := t.rows[id]; ok {
+		return nil, ErrDuplicateID
+	}
+	t.rows[id] = t.copy(*row)
+	t.order = append(t.order, id)
+	added := *row
+	t.notify(nil, &added)
+	return func() {
+		delete(t.rows, id)
+		t.order = t.order[:len(t.order)-1]
+		t.notify(&added, nil)
+	}, nil
+}
+
+// replace overwrites the row with the same ID and returns a function that
+// restores the previous version.
+func (t *table[T]) replace(row *T) (undo func(), err error) {
+	id := t.id(row)
+	old, ok := t.rows[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	t.rows[id] = t.copy(*row)
+	replaced := *row
+	t.notify(&old, &replaced)
+	return func() {
+		t.rows[id] = old
+		t.notify(&replaced, &old)
+	}, nil
+}
+
+// update loads the row with the given ID, lets fn modify a copy of it and
+// stores the result unless fn fails.
+func (t *table[T]) update(id string, fn func(*T) error) (updated *T, undo func(), err error) {
+	row, err := t.get(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := fn(row); err != nil {
+		return nil, nil, err
+	}
+	if t.id(row) != id {
+		return nil, nil, errIDChanged
+	}
+	undo, err = t.replace(row)
+	if err != nil {
+		return nil, nil, err
+	}
+	out := *row
+	return &out, undo, nil
+}
+
+// remove deletes the row with the given ID and returns a function that puts
+// it back in its original position.
+func (t *table[T]) remove(id string) (undo func(), err error) {
+	old, ok := t.rows[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	pos := slices.Index(t.order, id)
+	delete(t.rows, id)
+	t.order = slices.Delete(t.order, pos, pos+1)
+	t.notify(&old, nil)
+	return func() {
+		t.rows[id] = old
+		t.order = slices.Insert(t.order, pos, id)
+		t.notify(nil, &old)
+	}, nil
+}
+
+// all returns a copy of every row in insertion order.
+func (t *table[T]) all() []T {
+	out := make([]T, 0, len(t.order))
+	for _, id := range t.order {
+		out = append(out, t.copy(t.rows[id]))
+	}
+	return out
+}
+
+// MemoryStore is a Store that keeps everything in process memory. Its
+// contents are lost when the process exits. It is safe for concurrent use:
+// reads share a read lock and every mutation, including the persistence hook,
+// runs under the write lock.
+type MemoryStore struct {
+	mu         sync.RWMutex
+	users      *table[user.User]
+	listings   *table[listing.Listing]
+	categories *table[category.Category]
+
+	// listingCounts holds, per category ID, how many listings it directly
+	// contains in each status. It is maintained by the listings table's
+	// observer so reads never have to scan listings.
+	listingCounts map[string]map[listing.ListingStatus]int
+	// userIndex speeds up filtered user lists. Like listingCounts it is
+	// maintained by a table observer.
+	userIndex *userIndex
+	// listingText is the full-text index of listing titles and descriptions.
+	listingText *search.Index
+
+	// afterWrite, when set, is called under the write lock after every
+	// mutation. The file-backed store uses it to persist a snapshot; if it
+	// fails, the mutation is rolled back.
+	afterWrite func() error
+}
+
+// NewMemoryStore returns an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	s := &MemoryStore{
+		users:         newTable(func(u *user.User) string { return u.ID }),
+		listings:      newTable(func(l *listing.Listing) string { return l.ID }),
+		categories:    newTable(func(c *category.Category) string { return c.ID }),
+		listingCounts: make(map[string]map[listing.ListingStatus]int),
+		userIndex:     newUserIndex(),
+		listingText: search.NewIndex(
+			search.Field{Name: "title", Weight: 2},
+			search.Field{Name: "description", Weight: 1},
+		),
+	}
+	s.categories.clone = func(c *category.Category) { c.OldSlugs = slices.Clone(c.OldSlugs) }
+	s.listings.observe = func(old, new *listing.Listing) {
+		s.countListing(old, new)
+		s.indexListing(old, new)
+	}
+	s.users.observe = s.userIndex.update
+	return s
+}
+
+// indexListing keeps listingText in step with the listings table.
+func (s *MemoryStore) indexListing(old, new *listing.Listing) {
+	if new == nil {
+		s.listingText.Remove(old.ID)
+		return
+	}
+	if old == nil || old.Title != new.Title || old.Description != new.Description {
+		s.listingText.Add(new.ID, new.Title, new.Description)
+	}
+}
+
+// countListing moves a listing between the buckets of listingCounts.
+func (s *MemoryStore) countListing(old, new *listing.Listing) {
+	if old != nil {
+		byStatus := s.listingCounts[old.CategoryID]
+		if byStatus[old.Status]--; byStatus[old.Status] == 0 {
+			delete(byStatus, old.Status)
+		}
+		if len(byStatus) == 0 {
+			delete(s.listingCounts, old.CategoryID)
+		}
+	}
+	if new != nil {
+		byStatus := s.listingCounts[new.CategoryID]
+		if byStatus == nil {
+			byStatus = make(map[listing.ListingStatus]int)
+			s.listingCounts[new.CategoryID] = byStatus
+		}
+		byStatus[new.Status]++
+	}
+}
+
+func (s *MemoryStore) Users() UserRepository          { return memoryUsers{s} }
+func (s *MemoryStore) Listings() ListingRepository    { return memoryListings{s} }
+func (s *MemoryStore) Categories() CategoryRepository { return memoryCategories{s} }
+
+// Close is a no-op for the in-memory store.
+func (s *MemoryStore) Close() error { return nil }
+
+// pingInterval is how often Ping retries the read lock.
+const pingInterval = 10 * time.Millisecond
+
+// Ping waits for the read lock until ctx is done, so a store wedged by a
+// stuck writer fails. It polls rather than blocking in a goroutine that
+// would outlive ctx.
+func (s *MemoryStore) Ping(ctx context.Context) error {
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+	for !s.mu.TryRLock() {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return fmt.Errorf("database: store lock not available: %w", ctx.Err())
+		}
+	}
+	s.mu.RUnlock()
+	return nil
+}
+
+// read runs fn under the read lock.
+func (s *MemoryStore) read(fn func()) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	fn()
+}
+
+// mutate runs change under the write lock and persists the result. If
+// persisting fails, change is undone so memory never runs ahead of disk.
+func (s *MemoryStore) mutate(change func() (undo func(), err error)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	undo, err := change()
+	if err != nil {
+		return err
+	}
+	if s.afterWrite != nil {
+		if err := s.afterWrite(); err != nil {
+			undo()
+			return err
+		}
+	}
+	return nil
+}
+
+type memoryUsers struct{ s *MemoryStore }
+
+func (r memoryUsers) List(ctx context.Context, filter UserFilter) ([]user.User, error) {
+	var out []user.User
+	r.s.read(func() {
+		ids, ok := r.s.userIndex.candidates(filter)
+		if !ok {
+			ids = r.s.users.order
+		}
+		for _, id := range ids {
+			if u := r.s.users.rows[id]; filter.Matches(&u) {
+				out = append(out, u)
+			}
+		}
+	})
+	return out, nil
+}
+
+func (r memoryUsers) Get(ctx context.Context, id string) (u *user.User, err error) {
+	r.s.read(func() { u, err = r.s.users.get(id) })
+	return u, err
+}
+
+func (r memoryUsers) CountByStatus(ctx context.Context) (map[user.UserStatus]int, error) {
+	counts := make(map[user.UserStatus]int)
+	r.s.read(func() {
+		for status, ids := range r.s.userIndex.status {
+			counts[status] = len(ids)
+		}
+	})
+	return counts, nil
+}
+
+func (r memoryUsers) GetByEmail(ctx context.Context, email string) (found *user.User, err error) {
+	err = ErrNotFound
+	r.s.read(func() {
+		for _, id := range r.s.users.order {
+			if u := r.s.users.rows[id]; strings.EqualFold(u.Email, email) {
+				found, err = &u, nil
+				return
+			}
+		}
+	})
+	return found, err
+}
+
+func (r memoryUsers) Create(ctx context.Context, u *user.User) error {
+	return r.s.mutate(func() (func(), error) { return r.s.users.insert(u) })
+}
+
+func (r memoryUsers) Update(ctx context.Context, u *user.User) error {
+	return r.s.mutate(func() (func(), error) {
+		return r.keepAdmin(func() (func(), error) { return r.s.users.replace(u) })
+	})
+}
+
+func (r memoryUsers) UpdateFunc(ctx context.Context, id string, fn func(*user.User) error) (updated *user.User, err error) {
+	err = r.s.mutate(func() (func(), error) {
+		return r.keepAdmin(func() (undo func(), err error) {
+			updated, undo, err = r.s.users.update(id, fn)
+			return undo, err
+		})
+	})
+	return updated, err
+}
+
+// keepAdmin applies write and undoes it if it took away the last active
+// admin. A store that never had one, such as a fresh database, is left to
+// bootstrapping.
+func (r memoryUsers) keepAdmin(write func() (func(), error)) (func(), error) {
+	had := r.s.userIndex.activeAdmins()
+	undo, err := write()
+	if err != nil {
+		return nil, err
+	}
+	if had > 0 && r.s.userIndex.activeAdmins() == 0 {
+		undo()
+		return nil, user.ErrLastAdmin
+	}
+	return undo, nil
+}
+
+type memoryListings struct{ s *MemoryStore }
+
+func (r memoryListings) List(ctx context.Context, filter ListingFilter) ([]listing.Listing, error) {
+	var out []listing.Listing
+	r.s.read(func() {
+		matches := r.matcher(filter)
+		for _, id := range r.s.listings.order {
+			if l := r.s.listings.rows[id]; matches(&l) {
+				out = append(out, l)
+			}
+		}
+	})
+	return out, nil
+}
+
+func (r memoryListings) Search(ctx context.Context, query *search.Query, filter ListingFilter) ([]ListingMatch, error) {
+	var out []ListingMatch
+	r.s.read(func() {
+		matches := r.matcher(filter)
+		for _, hit := range r.s.listingText.Search(query) {
+			if l := r.s.listings.rows[hit.ID]; matches(&l) {
+				out = append(out, ListingMatch{Listing: l, Score: hit.Score, Highlights: hit.Highlights})
+			}
+		}
+	})
+	return out, nil
+}
+
+// matcher returns a predicate for filter. The caller must hold the lock.
+func (r memoryListings) matcher(filter ListingFilter) func(*listing.Listing) bool {
+	categories := map[string]bool{filter.CategoryID: true}
+	if filter.CategoryID != "" && filter.IncludeDescendants {
+		categories = category.Descendants(r.s.categories.all(), filter.CategoryID)
+	}
+	return func(l *listing.Listing) bool {
+		if filter.Status != "" && l.Status != filter.Status {
+			return false
+		}
+		return filter.CategoryID == "" || categories[l.CategoryID]
+	}
+}
+
+func (r memoryListings) Get(ctx context.Context, id string) (l *listing.Listing, err error) {
+	r.s.read(func() { l, err = r.s.listings.get(id) })
+	return l, err
+}
+
+func (r memoryListings) CountByStatus(ctx context.Context) (map[listing.ListingStatus]int, error) {
+	counts := make(map[listing.ListingStatus]int)
+	r.s.read(func() {
+		for _, byStatus := range r.s.listingCounts {
+			for status, n := range byStatus {
+				counts[status] += n
+			}
+		}
+	})
+	return counts, nil
+}
+
+func (r memoryListings) Create(ctx context.Context, l *listing.Listing) error {
+	return r.s.mutate(func() (func(), error) { return r.s.listings.insert(l) })
+}
+
+func (r memoryListings) Update(ctx context.Context, l *listing.Listing) error {
+	return r.s.mutate(func() (func(), error) { return r.s.listings.replace(l) })
+}
+
+func (r memoryListings) UpdateFunc(ctx context.Context, id string, fn func(*listing.Listing) error) (updated *listing.Listing, err error) {
+	err = r.s.mutate(func() (undo func(), err error) {
+		updated, undo, err = r.s.listings.update(id, fn)
+		return undo, err
+	})
+	return updated, err
+}
+
+type memoryCategories struct{ s *MemoryStore }
+
+func (r memoryCategories) List(ctx context.Context) (out []category.Category, err error) {
+	r.s.read(func() { out = r.s.categories.all() })
+	return out, nil
+}
+
+func (r memoryCategories) Get(ctx context.Context, id string) (c *category.Category, err error) {
+	r.s.read(func() { c, err = r.s.categories.get(id) })
+	return c, err
+}
+
+func (r memoryCategories) ListingCounts(ctx context.Context) (counts map[string]category.ListingCounts, err error) {
+	r.s.read(func() { counts = category.RollUp(r.s.categories.all(), r.s.listingCounts) })
+	return counts, nil
+}
+
+func (r memoryCategories) Create(ctx context.Context, c *category.Category, commit func() error) error {
+	return r.s.mutate(func() (func(), error) {
+		undo, err := r.s.categories.insert(c)
+		if undo, err = r.checked(c, undo, err); err != nil {
+			return nil, err
+		}
+		if commit != nil {
+			if err := commit(); err != nil {
+				undo()
+				return nil, err
+			}
+		}
+		return undo, nil
+	})
+}
+
+// checked undoes a change to c that leaves an invalid hierarchy or a slug
+// clash.
+func (r memoryCategories) checked(c *category.Category, undo func(), err error) (func(), error) {
+	if err != nil {
+		return nil, err
+	}
+	all := r.s.categories.all()
+	if err := category.CheckHierarchy(all); err != nil {
+		undo()
+		return nil, err
+	}
+	if err := category.CheckSlug(all, c); err != nil {
+		undo()
+		return nil, err
+	}
+	return undo, nil
+}
+
+func (r memoryCategories) UpdateFunc(ctx context.Context, id string, fn func(*category.Category) error) (updated *category.Category, err error) {
+	err = r.s.mutate(func() (undo func(), err error) {
+		updated, undo, err = r.s.categories.update(id, fn)
+		return r.checked(updated, undo, err)
+	})
+	return updated, err
+}
+
+func (r memoryCategories) Delete(ctx context.Context, id, reassignTo string, commit func(reassigned int) error) (reassigned int, err error) {
+	err = r.s.mutate(func() (func(), error) {
+		if _, err := r.s.categories.get(id); err != nil {
+			return nil, err
+		}
+		var inUse []string
+		for _, lid := range r.s.listings.order {
+			if r.s.listings.rows[lid].CategoryID == id {
+				inUse = append(inUse, lid)
+			}
+		}
+		if reassignTo != "" {
+			if _, err := r.s.categories.get(reassignTo); reassignTo == id || err != nil {
+				return nil, ErrInvalidReassignment
+			}
+		} else if len(inUse) > 0 {
+			return nil, fmt.Errorf("%w: %d listings", ErrCategoryInUse, len(inUse))
+		}
+
+		var undos []func()
+		undoAll := func() {
+			for i := len(undos) - 1; i >= 0; i-- {
+				undos[i]()
+			}
+		}
+		now := time.Now()
+		for _, lid := range inUse {
+			_, undo, err := r.s.listings.update(lid, func(l *listing.Listing) error {
+				l.CategoryID = reassignTo
+				l.LastUpdatedDate, l.UpdatedAt = now, now
+				return nil
+			})
+			if err != nil {
+				undoAll()
+				return nil, err
+			}
+			undos = append(undos, undo)
+		}
+		deleted, _ := r.s.categories.get(id)
+		for _, cid := range r.s.categories.order {
+			if r.s.categories.rows[cid].ParentCategoryID != id {
+				continue
+			}
+			_, undo, err := r.s.categories.update(cid, func(c *category.Category) error {
+				c.ParentCategoryID = deleted.ParentCategoryID
+				c.UpdatedAt = now
+				return nil
+			})
+			if err != nil {
+				undoAll()
+				return nil, err
+			}
+			undos = append(undos, undo)
+		}
+		undo, err := r.s.categories.remove(id)
+		if err != nil {
+			undoAll()
+			return nil, err
+		}
+		undos = append(undos, undo)
+		if commit != nil {
+			if err := commit(len(inUse)); err != nil {
+				undoAll()
+				return nil, err
+			}
+		}
+		reassigned = len(inUse)
+		return undoAll, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return reassigned, nil
+}
@@ -0,0 +1,306 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"reflect"
+	"testing"
+	"time"
+
+	"seattle-info-platform/internal/audit"
+	"seattle-info-platform/internal/category"
+	"seattle-info-platform/internal/listing"
+	"seattle-info-platform/internal/user"
+)
+
+// categoryStore returns a store holding the tree
+//
+//	home → furniture → chairs
+//	jobs
+//
+// with listings l1 and l2 in furniture and l3 in chairs, all last updated
+// at then.
+func categoryStore(t *testing.T, then time.Time) *MemoryStore {
+	t.Helper()
+	ctx := context.Background()
+	s := NewMemoryStore()
+	for _, c := range []category.Category{
+		{ID: "home", Slug: "home"},
+		{ID: "furniture", Slug: "furniture", ParentCategoryID: "home"},
+		{ID: "chairs", Slug: "chairs", ParentCategoryID: "furniture"},
+		{ID: "jobs", Slug: "jobs"},
+	} {
+		c.UpdatedAt = then
+		if err := s.Categories().Create(ctx, &c, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, l := range []listing.Listing{
+		{ID: "l1", CategoryID: "furniture", Status: listing.StatusActive},
+		{ID: "l2", CategoryID: "furniture", Status: listing.StatusPendingApproval},
+		{ID: "l3", CategoryID: "chairs", Status: listing.StatusActive},
+	} {
+		l.UpdatedAt, l.LastUpdatedDate = then, then
+		if err := s.Listings().Create(ctx, &l); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s
+}
+
+func TestDeleteCategoryReassigns(t *testing.T) {
+	ctx := context.Background()
+	then := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := categoryStore(t, then)
+
+	n, err := s.Categories().Delete(ctx, "furniture", "jobs", nil)
+	if err != nil || n != 2 {
+		t.Fatalf("Delete = %d, %v; want 2 reassigned", n, err)
+	}
+	if _, err := s.Categories().Get(ctx, "furniture"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("deleted category still there: %v", err)
+	}
+	for _, id := range []string{"l1", "l2"} {
+		l, _ := s.Listings().Get(ctx, id)
+		if l.CategoryID != "jobs" {
+			t.Errorf("%s is in %q, want jobs", id, l.CategoryID)
+		}
+		if !l.UpdatedAt.After(then) || !l.LastUpdatedDate.Equal(l.UpdatedAt) {
+			t.Errorf("%s UpdatedAt = %v, LastUpdatedDate = %v; want both bumped", id, l.UpdatedAt, l.LastUpdatedDate)
+		}
+	}
+	if l, _ := s.Listings().Get(ctx, "l3"); l.CategoryID != "chairs" || !l.UpdatedAt.Equal(then) {
+		t.Errorf("listing of a subcategory changed: %+v", l)
+	}
+	// The subcategory moves up to the deleted category's parent.
+	if c, _ := s.Categories().Get(ctx, "chairs"); c.ParentCategoryID != "home" || !c.UpdatedAt.After(then) {
+		t.Errorf("chairs = parent %q, updated %v; want home, bumped", c.ParentCategoryID, c.UpdatedAt)
+	}
+	counts, _ := s.Categories().ListingCounts(ctx)
+	if got := counts["jobs"].Own; got[listing.StatusActive] != 1 || got[listing.StatusPendingApproval] != 1 {
+		t.Errorf("jobs counts = %v", got)
+	}
+}
+
+func TestDeleteCategoryRefusals(t *testing.T) {
+	ctx := context.Background()
+	then := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		name, id, reassignTo string
+		want                 error
+	}{
+		{"in use", "furniture", "", ErrCategoryInUse},
+		{"reassign to itself", "furniture", "furniture", ErrInvalidReassignment},
+		{"reassign to missing", "furniture", "nope", ErrInvalidReassignment},
+		{"missing", "nope", "jobs", ErrNotFound},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := categoryStore(t, then)
+			if _, err := s.Categories().Delete(ctx, tc.id, tc.reassignTo, nil); !errors.Is(err, tc.want) {
+				t.Fatalf("Delete = %v, want %v", err, tc.want)
+			}
+			// Nothing changed.
+			if _, err := s.Categories().Get(ctx, "furniture"); err != nil {
+				t.Errorf("furniture: %v", err)
+			}
+			if l, _ := s.Listings().Get(ctx, "l1"); l.CategoryID != "furniture" || !l.UpdatedAt.Equal(then) {
+				t.Errorf("l1 changed: %+v", l)
+			}
+		})
+	}
+
+	// A category without listings needs no reassignment target.
+	s := categoryStore(t, then)
+	if n, err := s.Categories().Delete(ctx, "jobs", "", nil); err != nil || n != 0 {
+		t.Errorf("Delete empty category = %d, %v", n, err)
+	}
+}
+
+func TestCategoryHierarchyEnforced(t *testing.T) {
+	ctx := context.Background()
+	s := categoryStore(t, time.Time{})
+
+	if _, err := s.Categories().UpdateFunc(ctx, "home", func(c *category.Category) error {
+		c.ParentCategoryID = "chairs"
+		return nil
+	}); !errors.Is(err, category.ErrCycle) {
+		t.Errorf("UpdateFunc creating a cycle = %v, want ErrCycle", err)
+	}
+	if c, _ := s.Categories().Get(ctx, "home"); c.ParentCategoryID != "" {
+		t.Errorf("refused update left parent %q", c.ParentCategoryID)
+	}
+
+	// chairs is at depth 3; each further level goes one deeper.
+	parent := "chairs"
+	for depth := 4; depth <= category.MaxDepth+1; depth++ {
+		id := "level" + string(rune('0'+depth))
+		err := s.Categories().Create(ctx, &category.Category{ID: id, Slug: id, ParentCategoryID: parent}, nil)
+		if depth > category.MaxDepth {
+			if !errors.Is(err, category.ErrTooDeep) {
+				t.Errorf("Create at depth %d = %v, want ErrTooDeep", depth, err)
+			}
+			if _, err := s.Categories().Get(ctx, id); !errors.Is(err, ErrNotFound) {
+				t.Errorf("refused category %s was stored", id)
+			}
+			break
+		}
+		if err != nil {
+			t.Fatalf("Create at depth %d: %v", depth, err)
+		}
+		parent = id
+	}
+
+	// Moving a subtree under another category counts its full depth.
+	if _, err := s.Categories().UpdateFunc(ctx, "home", func(c *category.Category) error {
+		c.ParentCategoryID = "jobs"
+		return nil
+	}); !errors.Is(err, category.ErrTooDeep) {
+		t.Errorf("UpdateFunc nesting the tree too deep = %v, want ErrTooDeep", err)
+	}
+	if err := s.Categories().Create(ctx, &category.Category{ID: "lost", Slug: "lost", ParentCategoryID: "nope"}, nil); !errors.Is(err, category.ErrParentNotFound) {
+		t.Errorf("Create with a missing parent = %v, want ErrParentNotFound", err)
+	}
+}
+
+func TestListingCountsFollowWrites(t *testing.T) {
+	ctx := context.Background()
+	s := categoryStore(t, time.Time{})
+
+	if _, err := s.Listings().UpdateFunc(ctx, "l3", func(l *listing.Listing) error {
+		l.Status = listing.StatusAdminRemoved
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Listings().UpdateFunc(ctx, "l2", func(l *listing.Listing) error {
+		l.CategoryID = "jobs"
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	counts, err := s.Categories().ListingCounts(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]map[listing.ListingStatus]int{
+		"home":      {listing.StatusActive: 1, listing.StatusAdminRemoved: 1},
+		"furniture": {listing.StatusActive: 1, listing.StatusAdminRemoved: 1},
+		"chairs":    {listing.StatusAdminRemoved: 1},
+		"jobs":      {listing.StatusPendingApproval: 1},
+	}
+	for id, total := range want {
+		if got := counts[id].Total; !maps.Equal(got, total) {
+			t.Errorf("%s total = %v, want %v", id, got, total)
+		}
+	}
+	byStatus, _ := s.Listings().CountByStatus(ctx)
+	if byStatus[listing.StatusActive] != 1 || byStatus[listing.StatusAdminRemoved] != 1 || byStatus[listing.StatusPendingApproval] != 1 {
+		t.Errorf("CountByStatus = %v", byStatus)
+	}
+}
+
+func TestLastActiveAdminKept(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+	users := s.Users()
+	for _, u := range []user.User{
+		{ID: "a1", Role: user.RoleAdmin, Status: user.StatusActive},
+		{ID: "a2", Role: user.RoleAdmin, Status: user.StatusActive},
+		{ID: "u1", Role: user.RoleUser, Status: user.StatusActive},
+	} {
+		if err := users.Create(ctx, &u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	demote := func(u *user.User) error { u.Role = user.RoleModerator; return nil }
+
+	if _, err := users.UpdateFunc(ctx, "a1", demote); err != nil {
+		t.Fatalf("demoting one of two admins: %v", err)
+	}
+	if _, err := users.UpdateFunc(ctx, "a2", demote); !errors.Is(err, user.ErrLastAdmin) {
+		t.Errorf("demoting the last admin: %v, want ErrLastAdmin", err)
+	}
+	if _, err := users.UpdateFunc(ctx, "a2", func(u *user.User) error { u.Status = user.StatusSuspended; return nil }); !errors.Is(err, user.ErrLastAdmin) {
+		t.Errorf("suspending the last admin: %v, want ErrLastAdmin", err)
+	}
+	if err := users.Update(ctx, &user.User{ID: "a2", Role: user.RoleAdmin, Status: user.StatusInactive}); !errors.Is(err, user.ErrLastAdmin) {
+		t.Errorf("replacing the last admin: %v, want ErrLastAdmin", err)
+	}
+	if u, _ := users.Get(ctx, "a2"); u.Role != user.RoleAdmin || u.Status != user.StatusActive {
+		t.Errorf("a2 = %s %q after refusals, want an active admin", u.Role, u.Status)
+	}
+	if n := s.userIndex.activeAdmins(); n != 1 {
+		t.Errorf("index counts %d active admins after undo, want 1", n)
+	}
+	// Changes that leave the admin alone still go through.
+	if _, err := users.UpdateFunc(ctx, "u1", demote); err != nil {
+		t.Errorf("changing another user: %v", err)
+	}
+}
+
+func TestNoAdminStoreAcceptsUpdates(t *testing.T) {
+	// A fresh database has no admin to keep until one is bootstrapped.
+	ctx := context.Background()
+	users := NewMemoryStore().Users()
+	if err := users.Create(ctx, &user.User{ID: "u1", Role: user.RoleUser, Status: user.StatusPendingApproval}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := users.UpdateFunc(ctx, "u1", func(u *user.User) error { u.Status = user.StatusRejected; return nil }); err != nil {
+		t.Errorf("UpdateFunc without admins: %v", err)
+	}
+}
+
+// failingRecorder keeps the events it is given and refuses them all, so
+// every write through a service is rolled back.
+type failingRecorder struct{ events []audit.Event }
+
+func (r *failingRecorder) Record(ctx context.Context, e audit.Event) error {
+	r.events = append(r.events, e)
+	return errors.New("disk full")
+}
+
+// TestRenameKeepsStoredSlugs renames a category back to an old slug, which
+// rewrites OldSlugs, through a write that is then rejected. Neither the
+// stored row nor the audit snapshot of it may change.
+func TestRenameKeepsStoredSlugs(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+	olds := make([]string, 2, 4)
+	copy(olds, []string{"alpha", "beta"})
+	if err := s.Categories().Create(ctx, &category.Category{ID: "c1", Name: "Gamma", Slug: "gamma", OldSlugs: olds}, nil); err != nil {
+		t.Fatal(err)
+	}
+	olds[0] = "changed by the caller"
+
+	rec := &failingRecorder{}
+	_, err := category.NewService(s.Categories(), rec).Update(ctx, "c1", func(c *category.Category) error {
+		c.Name = "Alpha"
+		return nil
+	})
+	if err == nil {
+		t.Fatal("Update succeeded despite the failing recorder")
+	}
+	want := []string{"alpha", "beta"}
+	if len(rec.events) == 0 {
+		t.Fatal("nothing was recorded")
+	}
+	for _, e := range rec.events {
+		before := e.Before.(*category.Category)
+		after := e.After.(*category.Category)
+		if !reflect.DeepEqual(before.OldSlugs, want) {
+			t.Errorf("audit before OldSlugs = %q, want %q", before.OldSlugs, want)
+		}
+		if after.Slug != "alpha" || !reflect.DeepEqual(after.OldSlugs, []string{"beta", "gamma"}) {
+			t.Errorf("audit after = %q %q", after.Slug, after.OldSlugs)
+		}
+	}
+	c, err := s.Categories().Get(ctx, "c1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Slug != "gamma" || !reflect.DeepEqual(c.OldSlugs, want) {
+		t.Errorf("stored row = %q %q, want gamma %q", c.Slug, c.OldSlugs, want)
+	}
+}
@@ -0,0 +1,348 @@
+package database
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+var (
+	// ErrDirtySchema is returned when a previous migration failed part-way and
+	// the schema must be repaired (with `migrate down`) before use.
+	ErrDirtySchema = errors.New("database: schema is dirty")
+	// ErrPendingMigrations is returned when the schema is behind the migrations
+	// compiled into the binary.
+	ErrPendingMigrations = errors.New("database: schema has pending migrations")
+	// ErrChecksumMismatch is returned when an applied migration no longer
+	// matches its definition, i.e. a released migration was edited.
+	ErrChecksumMismatch = errors.New("database: migration checksum mismatch")
+	// ErrNoMigrationApplied is returned by Down when there is nothing to roll back.
+	ErrNoMigrationApplied = errors.New("database: no migration applied")
+)
+
+// OpKind names a schema operation.
+type OpKind string
+
+const (
+	OpCreateTable  OpKind = "create_table"
+	OpDropTable    OpKind = "drop_table"
+	OpAddColumn    OpKind = "add_column"
+	OpDropColumn   OpKind = "drop_column"
+	OpRenameColumn OpKind = "rename_column"
+)
+
+// Op is a single declarative schema operation. Operations are data rather
+// than code so that every migration has a stable checksum.
+type Op struct {
+	Kind   OpKind `json:"kind"`
+	Table  string `json:"table"`
+	Column string `json:"column,omitempty"`
+	// To is the new column name for OpRenameColumn.
+	To string `json:"to,omitempty"`
+	// Default is the JSON value written into every existing row by OpAddColumn.
+	Default json.RawMessage `json:"default,omitempty"`
+}
+
+// Migration is a numbered, reversible schema change.
+type Migration struct {
+	Version int
+	Name    string
+	Up      []Op
+	Down    []Op
+}
+
+// Checksum identifies the migration's definition. Changing a migration after
+// it has been applied anywhere is detected through this value.
+func (m Migration) Checksum() string {
+	data, err := json.Marshal(struct {
+		Version int    `json:"version"`
+		Name    string `json:"name"`
+		Up      []Op   `json:"up"`
+		Down    []Op   `json:"down"`
+	}{m.Version, m.Name, m.Up, m.Down})
+	if err != nil {
+		// Op only contains strings and raw JSON, so this cannot happen.
+		panic(fmt.Sprintf("database: checksum migration %d: %v", m.Version, err))
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// AppliedMigration is a row of the schema_migrations table.
+type AppliedMigration struct {
+	Version   int       `json:"version"`
+	Name      string    `json:"name"`
+	Checksum  string    `json:"checksum"`
+	AppliedAt time.Time `json:"applied_at"`
+	Dirty     bool      `json:"dirty,omitempty"`
+}
+
+// MigrationStatus describes one known migration relative to a database.
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+	Dirty     bool
+	// Modified reports that the applied checksum differs from the definition.
+	Modified bool
+}
+
+type row = map[string]json.RawMessage
+
+// apply runs op against the tables of doc.
+func (op Op) apply(tables map[string][]row) error {
+	rows, exists := tables[op.Table]
+	switch op.Kind {
+	case OpCreateTable:
+		if exists {
+			return fmt.Errorf("table %q already exists", op.Table)
+		}
+		tables[op.Table] = []row{}
+		return nil
+	case OpDropTable:
+		if !exists {
+			return fmt.Errorf("table %q does not exist", op.Table)
+		}
+		delete(tables, op.Table)
+		return nil
+	}
+
+	if !exists {
+		return fmt.Errorf("table %q does not exist", op.Table)
+	}
+	switch op.Kind {
+	case OpAddColumn:
+		if len(op.Default) == 0 || !json.Valid(op.Default) {
+			return fmt.Errorf("add column %s.%s: default must be valid JSON", op.Table, op.Column)
+		}
+		for _, r := range rows {
+			if _, ok := r[op.Column]; !ok {
+				r[op.Column] = op.Default
+			}
+		}
+	case OpDropColumn:
+		for _, r := range rows {
+			delete(r, op.Column)
+		}
+	case OpRenameColumn:
+		for _, r := range rows {
+			if v, ok := r[op.Column]; ok {
+				r[op.To] = v
+				delete(r, op.Column)
+			}
+		}
+	default:
+		return fmt.Errorf("unknown operation %q", op.Kind)
+	}
+	return nil
+}
+
+// Migrator applies and rolls back migrations on a database file. Up and Down
+// take the same file lock as FileStore and fail with ErrLocked while a
+// server has the database open.
+//
+// Each step is recorded as dirty and persisted before its operations run, and
+// the migrated tables are then written together with the clean record in one
+// atomic file replacement. A dirty record left behind therefore always means
+// the step failed before touching any table, and `Down` simply discards it.
+type Migrator struct {
+	path       string
+	migrations []Migration
+}
+
+// NewMigrator returns a migrator for the database file at path. The
+// migrations are sorted by version; versions must be unique and positive.
+func NewMigrator(path string, migrations []Migration) (*Migrator, error) {
+	sorted := append([]Migration(nil), migrations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+	for i, m := range sorted {
+		if m.Version <= 0 {
+			return nil, fmt.Errorf("database: migration %q has non-positive version %d", m.Name, m.Version)
+		}
+		if i > 0 && sorted[i-1].Version == m.Version {
+			return nil, fmt.Errorf("database: duplicate migration version %d", m.Version)
+		}
+	}
+	return &Migrator{path: path, migrations: sorted}, nil
+}
+
+// Status reports every known migration and whether it has been applied.
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	doc, err := readDocument(m.path)
+	if err != nil {
+		return nil, err
+	}
+	return migrationStatus(doc.Migrations, m.migrations), nil
+}
+
+// Up applies every pending migration in version order and returns the ones
+// that were applied.
+func (m *Migrator) Up() ([]Migration, error) {
+	lock, err := lockFile(m.path)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Close()
+	doc, err := readDocument(m.path)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyApplied(doc.Migrations, m.migrations); err != nil && !errors.Is(err, ErrPendingMigrations) {
+		return nil, err
+	}
+
+	applied := make(map[int]bool, len(doc.Migrations))
+	for _, am := range doc.Migrations {
+		applied[am.Version] = true
+	}
+
+	var done []Migration
+	for _, mig := range m.migrations {
+		if applied[mig.Version] {
+			continue
+		}
+		if err := m.step(doc, mig, mig.Up, true); err != nil {
+			return done, err
+		}
+		done = append(done, mig)
+	}
+	return done, nil
+}
+
+// Down rolls back the most recently applied migration and returns it. A dirty
+// migration is discarded without running its Down operations.
+func (m *Migrator) Down() (*Migration, error) {
+	lock, err := lockFile(m.path)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Close()
+	doc, err := readDocument(m.path)
+	if err != nil {
+		return nil, err
+	}
+	if len(doc.Migrations) == 0 {
+		return nil, ErrNoMigrationApplied
+	}
+	last := doc.Migrations[len(doc.Migrations)-1]
+	mig, ok := m.find(last.Version)
+	if !ok {
+		return nil, fmt.Errorf("database: applied migration %d is unknown to this binary", last.Version)
+	}
+
+	if last.Dirty {
+		doc.Migrations = doc.Migrations[:len(doc.Migrations)-1]
+		if err := doc.write(m.path); err != nil {
+			return nil, err
+		}
+		return &mig, nil
+	}
+	if last.Checksum != mig.Checksum() {
+		return nil, fmt.Errorf("%w: version %d", ErrChecksumMismatch, mig.Version)
+	}
+	if err := m.step(doc, mig, mig.Down, false); err != nil {
+		return nil, err
+	}
+	return &mig, nil
+}
+
+// step runs ops for mig and records the result. up selects whether the
+// migration is being applied or rolled back.
+func (m *Migrator) step(doc *document, mig Migration, ops []Op, up bool) error {
+	// A rollback removes its record in the same write that reverts the
+	// tables, so only forward steps need the dirty marker.
+	if up {
+		doc.Migrations = append(doc.Migrations, AppliedMigration{
+			Version:   mig.Version,
+			Name:      mig.Name,
+			Checksum:  mig.Checksum(),
+			AppliedAt: time.Now().UTC(),
+			Dirty:     true,
+		})
+		if err := doc.write(m.path); err != nil {
+			return err
+		}
+	}
+
+	tables, err := doc.rows()
+	if err != nil {
+		return fmt.Errorf("database: migration %d (%s): %w", mig.Version, mig.Name, err)
+	}
+	for _, op := range ops {
+		if err := op.apply(tables); err != nil {
+			return fmt.Errorf("database: migration %d (%s): %w", mig.Version, mig.Name, err)
+		}
+	}
+	if err := doc.setRows(tables); err != nil {
+		return fmt.Errorf("database: migration %d (%s): %w", mig.Version, mig.Name, err)
+	}
+
+	if up {
+		doc.Migrations[len(doc.Migrations)-1].Dirty = false
+	} else {
+		doc.Migrations = doc.Migrations[:len(doc.Migrations)-1]
+	}
+	return doc.write(m.path)
+}
+
+func (m *Migrator) find(version int) (Migration, bool) {
+	for _, mig := range m.migrations {
+		if mig.Version == version {
+			return mig, true
+		}
+	}
+	return Migration{}, false
+}
+
+func migrationStatus(applied []AppliedMigration, known []Migration) []MigrationStatus {
+	byVersion := make(map[int]AppliedMigration, len(applied))
+	for _, am := range applied {
+		byVersion[am.Version] = am
+	}
+	out := make([]MigrationStatus, 0, len(known))
+	for _, mig := range known {
+		st := MigrationStatus{Version: mig.Version, Name: mig.Name}
+		if am, ok := byVersion[mig.Version]; ok {
+			st.Applied = true
+			st.AppliedAt = am.AppliedAt
+			st.Dirty = am.Dirty
+			st.Modified = am.Checksum != mig.Checksum()
+		}
+		out = append(out, st)
+	}
+	return out
+}
+
+// verifyApplied checks that the applied migrations are clean, known, match
+// their checksums and that none of the known migrations is pending.
+func verifyApplied(applied []AppliedMigration, known []Migration) error {
+	byVersion := make(map[int]Migration, len(known))
+	for _, mig := range known {
+		byVersion[mig.Version] = mig
+	}
+	appliedVersions := make(map[int]bool, len(applied))
+	for _, am := range applied {
+		appliedVersions[am.Version] = true
+		if am.Dirty {
+			return fmt.Errorf("%w: migration %d (%s) did not complete; run `migrate down` to discard it", ErrDirtySchema, am.Version, am.Name)
+		}
+		mig, ok := byVersion[am.Version]
+		if !ok {
+			return fmt.Errorf("database: applied migration %d (%s) is unknown to this binary", am.Version, am.Name)
+		}
+		if am.Checksum != mig.Checksum() {
+			return fmt.Errorf("%w: version %d (%s)", ErrChecksumMismatch, am.Version, am.Name)
+		}
+	}
+	for _, mig := range known {
+		if !appliedVersions[mig.Version] {
+			return fmt.Errorf("%w: version %d (%s) not applied; run `migrate up`", ErrPendingMigrations, mig.Version, mig.Name)
+		}
+	}
+	return nil
+}
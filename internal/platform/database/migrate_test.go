@@ -0,0 +1,184 @@
+package database
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var testMigrations = []Migration{
+	{
+		Version: 1,
+		Name:    "create_items",
+		Up:      []Op{{Kind: OpCreateTable, Table: "items"}},
+		Down:    []Op{{Kind: OpDropTable, Table: "items"}},
+	},
+	{
+		Version: 2,
+		Name:    "add_item_color",
+		Up:      []Op{{Kind: OpAddColumn, Table: "items", Column: "color", Default: json.RawMessage(`"red"`)}},
+		Down:    []Op{{Kind: OpDropColumn, Table: "items", Column: "color"}},
+	},
+}
+
+func newTestMigrator(t *testing.T, path string, migrations []Migration) *Migrator {
+	t.Helper()
+	m, err := NewMigrator(path, migrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+// states summarizes Status as version:state pairs.
+func states(t *testing.T, m *Migrator) []string {
+	t.Helper()
+	statuses, err := m.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out []string
+	for _, st := range statuses {
+		state := "pending"
+		switch {
+		case st.Dirty:
+			state = "dirty"
+		case st.Modified:
+			state = "modified"
+		case st.Applied:
+			state = "applied"
+		}
+		out = append(out, st.Name+":"+state)
+	}
+	return out
+}
+
+// items reads the items table of the database file at path.
+func items(t *testing.T, path string) []map[string]string {
+	t.Helper()
+	doc, err := readDocument(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := decodeTable[map[string]string](doc, "items")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rows
+}
+
+func TestMigratorUpDown(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db.json")
+	m := newTestMigrator(t, path, testMigrations[:1])
+
+	if got, want := states(t, m), []string{"create_items:pending"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("status before Up = %q, want %q", got, want)
+	}
+	if done, err := m.Up(); err != nil || len(done) != 1 {
+		t.Fatalf("Up = %v, %v; want one migration", done, err)
+	}
+
+	// Rows written between migrations get the new column's default.
+	doc, err := readDocument(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc.Tables["items"] = json.RawMessage(`[{"id":"a"}]`)
+	if err := doc.write(path); err != nil {
+		t.Fatal(err)
+	}
+
+	m = newTestMigrator(t, path, testMigrations)
+	if got, want := states(t, m), []string{"create_items:applied", "add_item_color:pending"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("status after first Up = %q, want %q", got, want)
+	}
+	if done, err := m.Up(); err != nil || len(done) != 1 || done[0].Version != 2 {
+		t.Fatalf("second Up = %v, %v; want version 2", done, err)
+	}
+	if done, err := m.Up(); err != nil || len(done) != 0 {
+		t.Errorf("Up when up to date = %v, %v; want nothing", done, err)
+	}
+	if got, want := items(t, path), []map[string]string{{"id": "a", "color": "red"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("items after Up = %v, want %v", got, want)
+	}
+
+	if mig, err := m.Down(); err != nil || mig.Version != 2 {
+		t.Fatalf("Down = %v, %v; want version 2", mig, err)
+	}
+	if got, want := items(t, path), []map[string]string{{"id": "a"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("items after Down = %v, want %v", got, want)
+	}
+	if mig, err := m.Down(); err != nil || mig.Version != 1 {
+		t.Fatalf("second Down = %v, %v; want version 1", mig, err)
+	}
+	if doc, _ := readDocument(path); len(doc.Tables) != 0 || len(doc.Migrations) != 0 {
+		t.Errorf("document after rolling everything back = %+v", doc)
+	}
+	if _, err := m.Down(); !errors.Is(err, ErrNoMigrationApplied) {
+		t.Errorf("Down with nothing applied = %v, want ErrNoMigrationApplied", err)
+	}
+}
+
+func TestMigratorDirty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db.json")
+	broken := []Migration{testMigrations[0], {
+		Version: 2,
+		Name:    "add_to_missing_table",
+		Up:      []Op{{Kind: OpAddColumn, Table: "missing", Column: "x", Default: json.RawMessage(`1`)}},
+		Down:    []Op{{Kind: OpDropColumn, Table: "missing", Column: "x"}},
+	}}
+	m := newTestMigrator(t, path, broken)
+
+	done, err := m.Up()
+	if err == nil || len(done) != 1 {
+		t.Fatalf("Up = %v, %v; want the first migration and an error", done, err)
+	}
+	if got, want := states(t, m), []string{"create_items:applied", "add_to_missing_table:dirty"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("status after failed Up = %q, want %q", got, want)
+	}
+	if _, err := m.Up(); !errors.Is(err, ErrDirtySchema) {
+		t.Errorf("Up on a dirty schema = %v, want ErrDirtySchema", err)
+	}
+
+	// Down discards the dirty record without running its operations.
+	if mig, err := m.Down(); err != nil || mig.Version != 2 {
+		t.Fatalf("Down = %v, %v; want version 2", mig, err)
+	}
+	if got, want := states(t, m), []string{"create_items:applied", "add_to_missing_table:pending"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("status after Down = %q, want %q", got, want)
+	}
+}
+
+func TestMigratorChecksumMismatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db.json")
+	if _, err := newTestMigrator(t, path, testMigrations).Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	edited := append([]Migration(nil), testMigrations...)
+	edited[1].Up = []Op{{Kind: OpAddColumn, Table: "items", Column: "color", Default: json.RawMessage(`"blue"`)}}
+	m := newTestMigrator(t, path, edited)
+
+	if got, want := states(t, m), []string{"create_items:applied", "add_item_color:modified"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("status = %q, want %q", got, want)
+	}
+	if _, err := m.Up(); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Up = %v, want ErrChecksumMismatch", err)
+	}
+	if _, err := m.Down(); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Down = %v, want ErrChecksumMismatch", err)
+	}
+}
+
+func TestNewMigratorRejectsBadVersions(t *testing.T) {
+	for name, migrations := range map[string][]Migration{
+		"zero":      {{Version: 0, Name: "zero"}},
+		"duplicate": {{Version: 1, Name: "a"}, {Version: 1, Name: "b"}},
+	} {
+		if _, err := NewMigrator("db.json", migrations); err == nil {
+			t.Errorf("%s: NewMigrator succeeded", name)
+		}
+	}
+}
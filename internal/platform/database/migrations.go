@@ -0,0 +1,52 @@
+package database
+
+import "encoding/json"
+
+// Migrations is the schema history of the file-backed store, oldest first.
+// Released migrations must never be edited; add a new version instead.
+var Migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "create_core_tables",
+		Up: []Op{
+			{Kind: OpCreateTable, Table: "users"},
+			{Kind: OpCreateTable, Table: "listings"},
+			{Kind: OpCreateTable, Table: "categories"},
+		},
+		Down: []Op{
+			{Kind: OpDropTable, Table: "categories"},
+			{Kind: OpDropTable, Table: "listings"},
+			{Kind: OpDropTable, Table: "users"},
+		},
+	},
+	{
+		Version: 2,
+		Name:    "add_user_status_reason",
+		Up: []Op{
+			{Kind: OpAddColumn, Table: "users", Column: "status_reason", Default: json.RawMessage(`""`)},
+		},
+		Down: []Op{
+			{Kind: OpDropColumn, Table: "users", Column: "status_reason"},
+		},
+	},
+	{
+		Version: 3,
+		Name:    "add_category_parent",
+		Up: []Op{
+			{Kind: OpAddColumn, Table: "categories", Column: "parent_category_id", Default: json.RawMessage(`""`)},
+		},
+		Down: []Op{
+			{Kind: OpDropColumn, Table: "categories", Column: "parent_category_id"},
+		},
+	},
+	{
+		Version: 4,
+		Name:    "add_category_old_slugs",
+		Up: []Op{
+			{Kind: OpAddColumn, Table: "categories", Column: "old_slugs", Default: json.RawMessage(`[]`)},
+		},
+		Down: []Op{
+			{Kind: OpDropColumn, Table: "categories", Column: "old_slugs"},
+		},
+	},
+}
@@ -0,0 +1,87 @@
+package database
+
+import (
+	"slices"
+
+	"seattle-info-platform/internal/user"
+)
+
+// userIndex holds the secondary indexes of the users table.
+type userIndex struct {
+	status     setIndex[user.UserStatus]
+	role       setIndex[user.UserRole]
+	provider   setIndex[string]
+	verified   setIndex[bool]
+	registered timeIndex
+	text       trigramIndex
+}
+
+func newUserIndex() *userIndex {
+	return &userIndex{
+		status:   make(setIndex[user.UserStatus]),
+		role:     make(setIndex[user.UserRole]),
+		provider: make(setIndex[string]),
+		verified: make(setIndex[bool]),
+		text:     trigramIndex{postings: make(setIndex[string])},
+	}
+}
+
+// update is the users table observer.
+func (x *userIndex) update(old, new *user.User) {
+	if old != nil {
+		x.status.remove(old.Status, old.ID)
+		x.role.remove(old.Role, old.ID)
+		x.provider.remove(old.AuthProvider, old.ID)
+		x.verified.remove(old.IsEmailVerified, old.ID)
+		x.registered.remove(old.RegistrationDate, old.ID)
+		x.text.remove(searchText(old), old.ID)
+	}
+	if new != nil {
+		x.status.add(new.Status, new.ID)
+		x.role.add(new.Role, new.ID)
+		x.provider.add(new.AuthProvider, new.ID)
+		x.verified.add(new.IsEmailVerified, new.ID)
+		x.registered.add(new.RegistrationDate, new.ID)
+		x.text.add(searchText(new), new.ID)
+	}
+}
+
+// activeAdmins returns the number of active admin accounts.
+func (x *userIndex) activeAdmins() int {
+	return len(intersect(x.status[user.StatusActive], x.role[user.RoleAdmin]))
+}
+
+// candidates returns, sorted, the IDs of the users that may match f: a
+// superset of the matches that the caller narrows with f.Matches. ok is
+// false if no index applies and every user must be checked.
+func (x *userIndex) candidates(f UserFilter) (ids []string, ok bool) {
+	var sets []idSet
+	if f.Status != "" {
+		sets = append(sets, x.status[f.Status])
+	}
+	if f.Role != "" {
+		sets = append(sets, x.role[f.Role])
+	}
+	if f.AuthProvider != "" {
+		sets = append(sets, x.provider[f.AuthProvider])
+	}
+	if f.EmailVerified != nil {
+		sets = append(sets, x.verified[*f.EmailVerified])
+	}
+	if !f.RegisteredAfter.IsZero() || !f.RegisteredBefore.IsZero() {
+		sets = append(sets, x.registered.between(f.RegisteredAfter, f.RegisteredBefore))
+	}
+	for _, term := range f.terms() {
+		if c, ok := x.text.candidates(term); ok {
+			sets = append(sets, c)
+		}
+	}
+	if len(sets) == 0 {
+		return nil, false
+	}
+	for id := range intersect(sets...) {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids, true
+}
@@ -0,0 +1,88 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"seattle-info-platform/internal/user"
+)
+
+// TestUserListMatchesScan checks that indexed user lists return exactly the
+// users a full scan with UserFilter.Matches finds, also after updates.
+func TestUserListMatchesScan(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	statuses := []user.UserStatus{user.StatusPendingApproval, user.StatusActive, user.StatusSuspended}
+	roles := []user.UserRole{user.RoleUser, user.RoleModerator, user.RoleAdmin}
+	for i := 0; i < 60; i++ {
+		u := user.User{
+			ID:               fmt.Sprintf("u%02d", i),
+			Email:            fmt.Sprintf("person%d@%s.example", i, []string{"alpha", "beta"}[i%2]),
+			FirstName:        []string{"Ann", "Bob", "Carla", "Dmitri"}[i%4],
+			Status:           statuses[i%3],
+			Role:             roles[i%3],
+			AuthProvider:     []string{"firebase", "password"}[i%2],
+			IsEmailVerified:  i%5 != 0,
+			RegistrationDate: base.Add(time.Duration(i) * 24 * time.Hour),
+		}
+		if err := s.Users().Create(ctx, &u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Move some users so the indexes must follow updates.
+	for i := 0; i < 60; i += 7 {
+		if _, err := s.Users().UpdateFunc(ctx, fmt.Sprintf("u%02d", i), func(u *user.User) error {
+			u.Status, u.Role, u.FirstName = user.StatusInactive, user.RoleUser, "Zed"
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	all, _ := s.Users().List(ctx, UserFilter{})
+	if len(all) != 60 {
+		t.Fatalf("unfiltered list has %d users, want 60", len(all))
+	}
+
+	verified, unverified := true, false
+	for _, f := range []UserFilter{
+		{Status: user.StatusActive},
+		{Status: user.StatusInactive},
+		{Role: user.RoleAdmin, Status: user.StatusSuspended},
+		{Query: "carla"},
+		{Query: "ZED beta"},
+		{Query: "on2"},
+		{Query: "@"}, // too short for the trigram index
+		{AuthProvider: "password", EmailVerified: &verified},
+		{EmailVerified: &unverified},
+		{RegisteredAfter: base.Add(10 * 24 * time.Hour), RegisteredBefore: base.Add(20 * 24 * time.Hour)},
+		{RegisteredAfter: base.Add(50 * 24 * time.Hour), Query: "alpha"},
+	} {
+		got, err := s.Users().List(ctx, f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var want []string
+		for _, u := range all {
+			if f.Matches(&u) {
+				want = append(want, u.ID)
+			}
+		}
+		var gotIDs []string
+		for _, u := range got {
+			gotIDs = append(gotIDs, u.ID)
+		}
+		slices.Sort(gotIDs)
+		if !slices.Equal(gotIDs, want) {
+			t.Errorf("List(%+v) = %q, want %q", f, gotIDs, want)
+		}
+	}
+
+	counts, _ := s.Users().CountByStatus(ctx)
+	if counts[user.StatusInactive] != 9 {
+		t.Errorf("inactive count = %d, want 9", counts[user.StatusInactive])
+	}
+}
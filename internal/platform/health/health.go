@@ -0,0 +1,130 @@
+// Package health serves liveness and readiness probes.
+//
+// Liveness only says the process is serving HTTP. Readiness runs every
+// registered check concurrently and reports each one's status and latency;
+// it fails if any check fails or the server is shutting down, so load
+// balancers stop routing to it. Why a check failed is logged rather than
+// served, since probes are often reachable by anyone.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Statuses of a report and of each check.
+const (
+	StatusUp           = "up"
+	StatusDown         = "down"
+	StatusShuttingDown = "shutting_down"
+)
+
+// DefaultTimeout bounds each check unless Checks.Timeout says otherwise.
+const DefaultTimeout = 2 * time.Second
+
+// CheckFunc reports whether a dependency is usable. It must return once ctx
+// is done.
+type CheckFunc func(ctx context.Context) error
+
+// Result is the outcome of one check.
+type Result struct {
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+}
+
+// Report is the body of both probes.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks,omitempty"`
+}
+
+type check struct {
+	name string
+	fn   CheckFunc
+}
+
+// Checks holds the readiness checks. Register them before serving.
+type Checks struct {
+	// Timeout bounds each check; zero means DefaultTimeout.
+	Timeout time.Duration
+	// Stopping reports that the server is shutting down, which fails
+	// readiness without running the checks. It may be nil.
+	Stopping func() bool
+
+	checks []check
+}
+
+// Register adds a readiness check.
+func (c *Checks) Register(name string, fn CheckFunc) {
+	c.checks = append(c.checks, check{name, fn})
+	sort.Slice(c.checks, func(i, j int) bool { return c.checks[i].name < c.checks[j].name })
+}
+
+// Run runs every check concurrently and returns the report. Failed checks
+// are logged with their errors.
+func (c *Checks) Run(ctx context.Context) Report {
+	if c.Stopping != nil && c.Stopping() {
+		return Report{Status: StatusShuttingDown}
+	}
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	results := make([]Result, len(c.checks))
+	var wg sync.WaitGroup
+	for i, chk := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			start := time.Now()
+			err := chk.fn(ctx)
+			results[i] = Result{Status: StatusUp, LatencyMS: float64(time.Since(start).Microseconds()) / 1000}
+			if err != nil {
+				results[i].Status = StatusDown
+				slog.WarnContext(ctx, "readiness check failed", "check", chk.name, "err", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusUp, Checks: make(map[string]Result, len(c.checks))}
+	for i, chk := range c.checks {
+		report.Checks[chk.name] = results[i]
+		if results[i].Status != StatusUp {
+			report.Status = StatusDown
+		}
+	}
+	return report
+}
+
+// Ready serves the readiness probe: 200 if every check passed, 503
+// otherwise.
+func (c *Checks) Ready(w http.ResponseWriter, r *http.Request) {
+	report := c.Run(r.Context())
+	status := http.StatusOK
+	if report.Status != StatusUp {
+		status = http.StatusServiceUnavailable
+	}
+	write(w, status, report)
+}
+
+// Live serves the liveness probe, which succeeds whenever the server can
+// answer at all.
+func Live(w http.ResponseWriter, r *http.Request) {
+	write(w, http.StatusOK, Report{Status: StatusUp})
+}
+
+func write(w http.ResponseWriter, status int, report Report) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+}
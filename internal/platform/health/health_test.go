@@ -0,0 +1,107 @@
+package health
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReady(t *testing.T) {
+	const secret = "dial tcp 10.1.2.3:5432: connection refused"
+	for _, tc := range []struct {
+		name     string
+		stopping bool
+		dbErr    error
+		status   int
+		report   string
+	}{
+		{"up", false, nil, http.StatusOK, `{"checks":{"database":"up","slow":"up"},"status":"up"}`},
+		{"down", false, errors.New(secret), http.StatusServiceUnavailable, `{"checks":{"database":"down","slow":"up"},"status":"down"}`},
+		{"shutting down", true, nil, http.StatusServiceUnavailable, `{"status":"shutting_down"}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			defer slog.SetDefault(slog.Default())
+			slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+
+			c := &Checks{Stopping: func() bool { return tc.stopping }}
+			c.Register("slow", func(ctx context.Context) error {
+				time.Sleep(time.Millisecond)
+				return nil
+			})
+			c.Register("database", func(context.Context) error { return tc.dbErr })
+
+			w := httptest.NewRecorder()
+			c.Ready(w, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+			if w.Code != tc.status {
+				t.Errorf("status = %d, want %d", w.Code, tc.status)
+			}
+			if cc := w.Header().Get("Cache-Control"); cc != "no-store" {
+				t.Errorf("Cache-Control = %q, want no-store", cc)
+			}
+
+			// Compare statuses only; latencies vary.
+			var got struct {
+				Status string                     `json:"status"`
+				Checks map[string]json.RawMessage `json:"checks,omitempty"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			summary := map[string]any{"status": got.Status}
+			if got.Checks != nil {
+				checks := map[string]string{}
+				for name, raw := range got.Checks {
+					var r map[string]any
+					json.Unmarshal(raw, &r)
+					if len(r) != 2 || r["latency_ms"] == nil {
+						t.Errorf("check %s = %s, want only status and latency_ms", name, raw)
+					}
+					checks[name], _ = r["status"].(string)
+				}
+				summary["checks"] = checks
+			}
+			if b, _ := json.Marshal(summary); string(b) != tc.report {
+				t.Errorf("report = %s, want %s", b, tc.report)
+			}
+
+			if strings.Contains(w.Body.String(), secret) {
+				t.Errorf("response exposes the check error: %s", w.Body)
+			}
+			if log := logs.String(); tc.dbErr != nil && (!strings.Contains(log, "check=database") || !strings.Contains(log, "connection refused")) {
+				t.Errorf("the failure was not logged: %q", log)
+			}
+		})
+	}
+}
+
+func TestRunTimeout(t *testing.T) {
+	c := &Checks{Timeout: 10 * time.Millisecond}
+	c.Register("stuck", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	start := time.Now()
+	report := c.Run(context.Background())
+	if report.Status != StatusDown || report.Checks["stuck"].Status != StatusDown {
+		t.Errorf("report = %+v, want the stuck check down", report)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("Run took %v despite the timeout", d)
+	}
+}
+
+func TestLive(t *testing.T) {
+	w := httptest.NewRecorder()
+	Live(w, httptest.NewRequest(http.MethodGet, "/health/live", nil))
+	if w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != `{"status":"up"}` {
+		t.Errorf("Live = %d %s", w.Code, w.Body)
+	}
+}
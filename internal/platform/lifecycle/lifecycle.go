@@ -0,0 +1,172 @@
+// Package lifecycle starts and stops the subsystems of a process in order.
+//
+// Subsystems register hooks. Start runs the start hooks in registration
+// order; Stop runs the stop hooks of every started subsystem in reverse
+// order, so a subsystem is stopped before whatever it depends on. Run ties
+// both to a context, typically cancelled by a termination signal.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Hook is one subsystem's part in the lifecycle. Either function may be nil.
+type Hook struct {
+	Name string
+	// Start must not block; long-running work belongs in a goroutine that
+	// reports failure with Manager.Fail.
+	Start func(ctx context.Context) error
+	// Stop must return once ctx is done, even if it has not finished.
+	Stop func(ctx context.Context) error
+}
+
+// State is the phase a Manager is in.
+type State int32
+
+const (
+	Idle State = iota
+	Starting
+	Running
+	Stopping
+	Stopped
+)
+
+func (s State) String() string {
+	switch s {
+	case Idle:
+		return "idle"
+	case Starting:
+		return "starting"
+	case Running:
+		return "running"
+	case Stopping:
+		return "stopping"
+	case Stopped:
+		return "stopped"
+	}
+	return fmt.Sprintf("State(%d)", int(s))
+}
+
+// Manager runs hooks. It is safe for concurrent use, but hooks must be
+// appended before Start.
+type Manager struct {
+	mu      sync.Mutex
+	hooks   []Hook
+	started int // hooks[:started] have been started
+	state   State
+	failed  chan error
+}
+
+// New returns a manager without hooks.
+func New() *Manager {
+	return &Manager{failed: make(chan error, 1)}
+}
+
+// Append registers h after the hooks already registered.
+func (m *Manager) Append(h Hook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks = append(m.hooks, h)
+}
+
+// State returns the current phase.
+func (m *Manager) State() State {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state
+}
+
+func (m *Manager) setState(s State) {
+	m.mu.Lock()
+	m.state = s
+	m.mu.Unlock()
+}
+
+// Fail reports that a started subsystem can no longer work, which makes Run
+// shut down and return err. Only the first failure is kept.
+func (m *Manager) Fail(err error) {
+	select {
+	case m.failed <- err:
+	default:
+	}
+}
+
+// Start runs the start hooks in order. If one fails, the hooks already
+// started are stopped and the error is returned.
+func (m *Manager) Start(ctx context.Context) error {
+	m.setState(Starting)
+	for {
+		m.mu.Lock()
+		if m.started == len(m.hooks) {
+			m.state = Running
+			m.mu.Unlock()
+			return nil
+		}
+		h := m.hooks[m.started]
+		m.mu.Unlock()
+
+		if h.Start != nil {
+			if err := h.Start(ctx); err != nil {
+				err = fmt.Errorf("start %s: %w", h.Name, err)
+				return errors.Join(err, m.Stop(context.WithoutCancel(ctx)))
+			}
+		}
+		slog.Debug("started", "subsystem", h.Name)
+		m.mu.Lock()
+		m.started++
+		m.mu.Unlock()
+	}
+}
+
+// Stop runs the stop hooks of the started subsystems in reverse order and
+// returns every error. Each hook runs even if an earlier one failed or ctx
+// expired, so resources are released as far as possible.
+func (m *Manager) Stop(ctx context.Context) error {
+	m.setState(Stopping)
+	var errs []error
+	for {
+		m.mu.Lock()
+		if m.started == 0 {
+			m.state = Stopped
+			m.mu.Unlock()
+			return errors.Join(errs...)
+		}
+		m.started--
+		h := m.hooks[m.started]
+		m.mu.Unlock()
+
+		if h.Stop == nil {
+			continue
+		}
+		begin := time.Now()
+		if err := h.Stop(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("stop %s: %w", h.Name, err))
+			continue
+		}
+		slog.Info("stopped", "subsystem", h.Name, "duration", time.Since(begin))
+	}
+}
+
+// Run starts every hook, waits until ctx is done or a subsystem fails and
+// then stops every hook, allowing them drain to finish. It returns the
+// failure, if any, joined with the errors of Start or Stop.
+func (m *Manager) Run(ctx context.Context, drain time.Duration) error {
+	if err := m.Start(ctx); err != nil {
+		return err
+	}
+	var failure error
+	select {
+	case <-ctx.Done():
+		slog.Info("shutting down", "drain_timeout", drain)
+	case failure = <-m.failed:
+		slog.Error("shutting down after failure", "err", failure)
+	}
+	stopCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), drain)
+	defer cancel()
+	return errors.Join(failure, m.Stop(stopCtx))
+}
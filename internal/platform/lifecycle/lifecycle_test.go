@@ -0,0 +1,141 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recorder appends hooks that note when they start and stop.
+type recorder struct {
+	m      *Manager
+	events []string
+}
+
+func (r *recorder) add(name string, startErr, stopErr error) {
+	r.m.Append(Hook{
+		Name: name,
+		Start: func(context.Context) error {
+			r.events = append(r.events, "start "+name)
+			return startErr
+		},
+		Stop: func(context.Context) error {
+			r.events = append(r.events, "stop "+name)
+			return stopErr
+		},
+	})
+}
+
+func (r *recorder) check(t *testing.T, want ...string) {
+	t.Helper()
+	if got := strings.Join(r.events, ", "); got != strings.Join(want, ", ") {
+		t.Errorf("events = %s\nwant     %s", got, strings.Join(want, ", "))
+	}
+}
+
+func TestStartStopOrder(t *testing.T) {
+	r := &recorder{m: New()}
+	r.add("database", nil, nil)
+	r.m.Append(Hook{Name: "no-op"})
+	r.add("http", nil, nil)
+
+	if err := r.m.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if s := r.m.State(); s != Running {
+		t.Errorf("state = %s, want running", s)
+	}
+	if err := r.m.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if s := r.m.State(); s != Stopped {
+		t.Errorf("state = %s, want stopped", s)
+	}
+	r.check(t, "start database", "start http", "stop http", "stop database")
+
+	// Stopping again has nothing left to stop.
+	if err := r.m.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	r.check(t, "start database", "start http", "stop http", "stop database")
+}
+
+func TestStartRollsBack(t *testing.T) {
+	r := &recorder{m: New()}
+	r.add("database", nil, nil)
+	r.add("audit", nil, errors.New("flush failed"))
+	r.add("http", errors.New("address in use"), nil)
+	r.add("readiness", nil, nil)
+
+	err := r.m.Start(context.Background())
+	for _, want := range []string{"start http: address in use", "stop audit: flush failed"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Start error %v does not mention %q", err, want)
+		}
+	}
+	r.check(t, "start database", "start audit", "start http", "stop audit", "stop database")
+	if s := r.m.State(); s != Stopped {
+		t.Errorf("state = %s, want stopped", s)
+	}
+}
+
+func TestStopJoinsErrors(t *testing.T) {
+	r := &recorder{m: New()}
+	r.add("a", nil, errors.New("a broke"))
+	r.add("b", nil, errors.New("b broke"))
+	if err := r.m.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	err := r.m.Stop(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "stop a: a broke") || !strings.Contains(err.Error(), "stop b: b broke") {
+		t.Errorf("Stop error = %v, want both failures", err)
+	}
+	r.check(t, "start a", "start b", "stop b", "stop a")
+}
+
+func TestRun(t *testing.T) {
+	t.Run("cancelled", func(t *testing.T) {
+		r := &recorder{m: New()}
+		r.add("a", nil, nil)
+		var deadline bool
+		r.m.Append(Hook{Name: "drain", Stop: func(ctx context.Context) error {
+			_, deadline = ctx.Deadline()
+			return ctx.Err()
+		}})
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if err := r.m.Run(ctx, time.Minute); err != nil {
+			t.Fatalf("Run = %v; stop hooks must not see the cancelled context", err)
+		}
+		if !deadline {
+			t.Error("stop hooks got no drain deadline")
+		}
+		r.check(t, "start a", "stop a")
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		r := &recorder{m: New()}
+		r.add("a", nil, nil)
+		failure := errors.New("listener died")
+		r.m.Append(Hook{Name: "server", Start: func(context.Context) error {
+			go r.m.Fail(failure)
+			return nil
+		}})
+		r.m.Fail(errors.New("ignored")) // only the first failure is kept
+		err := r.m.Run(context.Background(), time.Minute)
+		if err == nil || !strings.Contains(err.Error(), "ignored") {
+			t.Errorf("Run = %v, want the first failure", err)
+		}
+		r.check(t, "start a", "stop a")
+	})
+}
+
+func TestStateString(t *testing.T) {
+	for s, want := range map[State]string{Idle: "idle", Running: "running", State(9): "State(9)"} {
+		if got := s.String(); got != want {
+			t.Errorf("%d.String() = %q, want %q", s, got, want)
+		}
+	}
+}
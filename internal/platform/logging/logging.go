@@ -0,0 +1,103 @@
+// Package logging sets up structured logging with log/slog. Records logged
+// with a request's context carry its request ID and whatever the request's
+// handlers added with Add, such as the authenticated admin.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"seattle-info-platform/internal/platform/requestid"
+)
+
+// Output formats.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// ParseLevel parses "debug", "info", "warn" or "error", ignoring case.
+func ParseLevel(s string) (slog.Level, error) {
+	var l slog.Level
+	err := l.UnmarshalText([]byte(s))
+	return l, err
+}
+
+// New returns a logger writing records at level or above to w in format.
+func New(w io.Writer, level, format string) (*slog.Logger, error) {
+	l, err := ParseLevel(level)
+	if err != nil {
+		return nil, fmt.Errorf("logging: %w", err)
+	}
+	opts := &slog.HandlerOptions{Level: l}
+	var h slog.Handler
+	switch strings.ToLower(format) {
+	case FormatText:
+		h = slog.NewTextHandler(w, opts)
+	case FormatJSON:
+		h = slog.NewJSONHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("logging: unknown format %q", format)
+	}
+	return slog.New(contextHandler{h}), nil
+}
+
+// contextHandler adds the request attributes found in the context to every
+// record.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := requestid.FromContext(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if st := stateFrom(ctx); st != nil {
+		r.AddAttrs(st.attrs()...)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// state is the per-request data that Middleware logs and that handlers
+// deeper in the chain fill in.
+type state struct {
+	mu    sync.Mutex
+	route string
+	extra []slog.Attr
+}
+
+func (s *state) attrs() []slog.Attr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]slog.Attr(nil), s.extra...)
+}
+
+type stateKey struct{}
+
+func stateFrom(ctx context.Context) *state {
+	st, _ := ctx.Value(stateKey{}).(*state)
+	return st
+}
+
+// Add attaches attrs to every later record logged with the context of the
+// request that ctx belongs to, including the request's access log line.
+// Outside a request served by Middleware it does nothing.
+func Add(ctx context.Context, attrs ...slog.Attr) {
+	if st := stateFrom(ctx); st != nil {
+		st.mu.Lock()
+		st.extra = append(st.extra, attrs...)
+		st.mu.Unlock()
+	}
+}
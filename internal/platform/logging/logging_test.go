@@ -0,0 +1,101 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"seattle-info-platform/internal/platform/requestid"
+)
+
+func TestNew(t *testing.T) {
+	for _, tc := range []struct {
+		level, format string
+		ok            bool
+	}{
+		{"info", "text", true},
+		{"DEBUG", "JSON", true},
+		{"loud", "text", false},
+		{"info", "xml", false},
+	} {
+		if _, err := New(&bytes.Buffer{}, tc.level, tc.format); (err == nil) != tc.ok {
+			t.Errorf("New(%q, %q) error = %v, want ok %v", tc.level, tc.format, err, tc.ok)
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	var out bytes.Buffer
+	logger, err := New(&out, "info", FormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(logger)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		Add(r.Context(), slog.String("admin", "u1"))
+		slog.InfoContext(r.Context(), "handled")
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte("short and stout"))
+	})
+	h := requestid.Middleware(Middleware(Route("/api", mux)))
+	r := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	r.Header.Set(requestid.Header, "req-1")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	var lines []map[string]any
+	dec := json.NewDecoder(&out)
+	for dec.More() {
+		var line map[string]any
+		if err := dec.Decode(&line); err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) != 2 {
+		t.Fatalf("logged %d lines, want the handler's and the access log:\n%s", len(lines), out.String())
+	}
+	for _, line := range lines {
+		if line["request_id"] != "req-1" || line["admin"] != "u1" {
+			t.Errorf("line lacks the request attributes: %v", line)
+		}
+	}
+	access := lines[1]
+	for key, want := range map[string]any{
+		"msg":    "request",
+		"method": "GET",
+		"route":  "/api/users/{id}",
+		"path":   "/users/42",
+		"status": float64(http.StatusTeapot),
+		"bytes":  float64(len("short and stout")),
+	} {
+		if access[key] != want {
+			t.Errorf("access log %s = %v, want %v", key, access[key], want)
+		}
+	}
+}
+
+func TestOutsideRequest(t *testing.T) {
+	// Add and RoutePattern ignore contexts that Middleware did not create.
+	ctx := context.Background()
+	Add(ctx, slog.String("admin", "u1"))
+	if got := RoutePattern(ctx); got != "" {
+		t.Errorf("RoutePattern = %q, want empty", got)
+	}
+
+	rec := &Recorder{ResponseWriter: httptest.NewRecorder()}
+	if rec.Status() != http.StatusOK {
+		t.Errorf("Status before writing = %d, want 200", rec.Status())
+	}
+	rec.WriteHeader(http.StatusNotFound)
+	rec.WriteHeader(http.StatusInternalServerError)
+	if rec.Status() != http.StatusNotFound {
+		t.Errorf("Status = %d, want the first code written", rec.Status())
+	}
+}
@@ -0,0 +1,120 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Middleware logs every request once it has been served: method, route
+// pattern, path, status, response size and latency. It must run inside
+// requestid.Middleware so the line carries the request ID.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		st := &state{}
+		ctx := context.WithValue(r.Context(), stateKey{}, st)
+		rec := &Recorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		level := slog.LevelInfo
+		if rec.Status() >= 500 {
+			level = slog.LevelError
+		}
+		st.mu.Lock()
+		route := st.route
+		st.mu.Unlock()
+		slog.LogAttrs(ctx, level, "request",
+			slog.String("method", r.Method),
+			slog.String("route", route),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", rec.Status()),
+			slog.Int64("bytes", rec.Bytes()),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("remote_addr", r.RemoteAddr),
+		)
+	})
+}
+
+// Router is a mux that can report the route a request matches, such as
+// *http.ServeMux or *problem.Mux.
+type Router interface {
+	http.Handler
+	Handler(r *http.Request) (h http.Handler, pattern string)
+}
+
+// Route wraps mux so that Middleware logs the pattern of the route mux
+// matches. Nested muxes each record their match and the innermost wins;
+// prefix restores any path prefix stripped before the request reached mux.
+func Route(prefix string, mux Router) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if st := stateFrom(r.Context()); st != nil {
+			if _, pattern := mux.Handler(r); pattern != "" {
+				// Drop the method; it is logged on its own.
+				if _, path, ok := strings.Cut(pattern, " "); ok {
+					pattern = path
+				}
+				st.mu.Lock()
+				st.route = prefix + pattern
+				st.mu.Unlock()
+			}
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// RoutePattern returns the route pattern recorded by Route for the request
+// ctx belongs to, or "" if no route matched.
+func RoutePattern(ctx context.Context) string {
+	st := stateFrom(ctx)
+	if st == nil {
+		return ""
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.route
+}
+
+// Recorder captures the status and size of a response. Middleware uses it
+// for the access log; other middleware may too.
+type Recorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+// Status returns the status code written, 200 if the handler wrote none.
+func (r *Recorder) Status() int {
+	if r.status == 0 {
+		return http.StatusOK
+	}
+	return r.status
+}
+
+// Bytes returns the size of the response body written so far.
+func (r *Recorder) Bytes() int64 {
+	return r.bytes
+}
+
+func (r *Recorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *Recorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (r *Recorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
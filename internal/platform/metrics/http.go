@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"seattle-info-platform/internal/platform/logging"
+)
+
+// HTTP instruments request handling with per-route request counts and
+// latencies and the number of requests in flight.
+type HTTP struct {
+	requests *Counter
+	duration *Histogram
+	inFlight *Gauge
+	route    func(*http.Request) string
+}
+
+// NewHTTP registers the HTTP metrics on reg. route names the route pattern
+// a served request matched, "" if none; raw paths are never used as labels,
+// and methods other than the standard ones are counted as "other", so the
+// number of series stays bounded.
+func NewHTTP(reg *Registry, route func(*http.Request) string) *HTTP {
+	return &HTTP{
+		requests: reg.NewCounter("http_requests_total", "HTTP requests served, by route pattern and status.", "method", "route", "status"),
+		duration: reg.NewHistogram("http_request_duration_seconds", "HTTP request latency, by route pattern.", DefBuckets, "method", "route"),
+		inFlight: reg.NewGauge("http_requests_in_flight", "HTTP requests being served."),
+		route:    route,
+	}
+}
+
+// Middleware records every request served by next.
+func (m *HTTP) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		m.inFlight.Add(1)
+		defer m.inFlight.Add(-1)
+
+		rec := &logging.Recorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+
+		route := m.route(r)
+		if route == "" {
+			route = "unmatched"
+		}
+		method := methodLabel(r.Method)
+		m.requests.Inc(method, route, strconv.Itoa(rec.Status()))
+		m.duration.Observe(time.Since(start).Seconds(), method, route)
+	})
+}
+
+// methodLabel returns method if it is a standard HTTP method and "other"
+// otherwise; clients may send any token as the method.
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	}
+	return "other"
+}
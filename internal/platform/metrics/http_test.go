@@ -0,0 +1,46 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHTTPMiddlewareLabels(t *testing.T) {
+	reg := NewRegistry()
+	m := NewHTTP(reg, func(r *http.Request) string {
+		if r.URL.Path == "/known" {
+			return "/known"
+		}
+		return ""
+	})
+	h := m.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/known" {
+			http.NotFound(w, r)
+		}
+	}))
+	for _, method := range []string{"GET", "ZZ1", "ZZ2", "ZZ3", "DELETE"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, "/known", nil))
+	}
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/unknown/path", nil))
+
+	var out strings.Builder
+	if err := reg.WriteText(&out); err != nil {
+		t.Fatal(err)
+	}
+	text := out.String()
+	for _, want := range []string{
+		`http_requests_total{method="GET",route="/known",status="200"} 1`,
+		`http_requests_total{method="DELETE",route="/known",status="200"} 1`,
+		`http_requests_total{method="other",route="/known",status="200"} 3`,
+		`http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("metrics lack %s:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "ZZ") {
+		t.Errorf("client-supplied methods became labels:\n%s", text)
+	}
+}
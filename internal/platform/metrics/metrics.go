@@ -0,0 +1,320 @@
+// Package metrics implements the few Prometheus metric types the server
+// needs and serves them in the Prometheus text exposition format.
+//
+// Metrics are registered once on a Registry and then updated with label
+// values given in the order of the label names:
+//
+//	requests := reg.NewCounter("http_requests_total", "Requests served.", "method", "status")
+//	requests.Inc("GET", "200")
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the media type of the text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefBuckets are the default histogram buckets, in seconds, suited to
+// request latencies.
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// metric is one registered metric family.
+type metric interface {
+	// write appends the family's samples, without HELP and TYPE lines.
+	write(w *bufio.Writer, name string)
+}
+
+type family struct {
+	name, help, typ string
+	metric          metric
+}
+
+// Registry holds metrics and serves them. It is safe for concurrent use.
+type Registry struct {
+	mu       sync.Mutex
+	families []family
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) register(name, help, typ string, m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, f := range r.families {
+		if f.name == name {
+			panic("metrics: duplicate metric " + name)
+		}
+	}
+	r.families = append(r.families, family{name: name, help: help, typ: typ, metric: m})
+	sort.Slice(r.families, func(i, j int) bool { return r.families[i].name < r.families[j].name })
+}
+
+// WriteText writes every metric in the text exposition format.
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	families := append([]family(nil), r.families...)
+	r.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, f := range families {
+		fmt.Fprintf(bw, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+		fmt.Fprintf(bw, "# TYPE %s %s\n", f.name, f.typ)
+		f.metric.write(bw, f.name)
+	}
+	return bw.Flush()
+}
+
+// Handler serves the registry's metrics.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		r.WriteText(w)
+	})
+}
+
+// vec holds one value per combination of label values.
+type vec[V any] struct {
+	labels []string
+	mu     sync.Mutex
+	series map[string]*series[V]
+}
+
+type series[V any] struct {
+	values []string
+	v      V
+}
+
+func newVec[V any](labels []string) vec[V] {
+	return vec[V]{labels: labels, series: make(map[string]*series[V])}
+}
+
+// with calls fn with the series of values, creating it with init if needed,
+// under the vec's lock.
+func (v *vec[V]) with(values []string, init func() V, fn func(*V)) {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: got %d label values for labels %v", len(values), v.labels))
+	}
+	key := strings.Join(values, "\xff")
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	s := v.series[key]
+	if s == nil {
+		s = &series[V]{values: append([]string(nil), values...), v: init()}
+		v.series[key] = s
+	}
+	fn(&s.v)
+}
+
+// each calls fn for every series, ordered by label values, under the lock.
+func (v *vec[V]) each(fn func(labels string, value *V)) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	keys := make([]string, 0, len(v.series))
+	for k := range v.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		s := v.series[k]
+		fn(formatLabels(v.labels, s.values), &s.v)
+	}
+}
+
+// Counter is a monotonically increasing value per label combination.
+type Counter struct{ vec[float64] }
+
+// NewCounter registers a counter with the given label names.
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{newVec[float64](labels)}
+	r.register(name, help, "counter", c)
+	return c
+}
+
+// Inc adds 1 to the counter of the label values.
+func (c *Counter) Inc(values ...string) { c.Add(1, values...) }
+
+// Add adds delta, which must not be negative, to the counter of the label
+// values.
+func (c *Counter) Add(delta float64, values ...string) {
+	if delta < 0 {
+		panic("metrics: counter decreased")
+	}
+	c.with(values, zero, func(v *float64) { *v += delta })
+}
+
+func (c *Counter) write(w *bufio.Writer, name string) {
+	c.each(func(labels string, v *float64) { writeSample(w, name, labels, *v) })
+}
+
+// Gauge is a value that can go up and down, per label combination.
+type Gauge struct{ vec[float64] }
+
+// NewGauge registers a gauge with the given label names.
+func (r *Registry) NewGauge(name, help string, labels ...string) *Gauge {
+	g := &Gauge{newVec[float64](labels)}
+	r.register(name, help, "gauge", g)
+	return g
+}
+
+// Add adds delta to the gauge of the label values.
+func (g *Gauge) Add(delta float64, values ...string) {
+	g.with(values, zero, func(v *float64) { *v += delta })
+}
+
+// Set sets the gauge of the label values.
+func (g *Gauge) Set(value float64, values ...string) {
+	g.with(values, zero, func(v *float64) { *v = value })
+}
+
+func (g *Gauge) write(w *bufio.Writer, name string) {
+	g.each(func(labels string, v *float64) { writeSample(w, name, labels, *v) })
+}
+
+// GaugeFunc is a gauge whose values are computed when metrics are
+// collected.
+type GaugeFunc struct {
+	label   string
+	collect func() (map[string]float64, error)
+}
+
+// NewGaugeFunc registers a gauge with a single label whose values collect
+// returns, keyed by label value, on every scrape. If collect fails the
+// family is exported without samples.
+func (r *Registry) NewGaugeFunc(name, help, label string, collect func() (map[string]float64, error)) {
+	r.register(name, help, "gauge", &GaugeFunc{label: label, collect: collect})
+}
+
+func (g *GaugeFunc) write(w *bufio.Writer, name string) {
+	values, err := g.collect()
+	if err != nil {
+		fmt.Fprintf(w, "# collect %s failed: %s\n", name, escapeHelp(err.Error()))
+		return
+	}
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		writeSample(w, name, formatLabels([]string{g.label}, []string{k}), values[k])
+	}
+}
+
+// Histogram counts observations in cumulative buckets per label
+// combination.
+type Histogram struct {
+	vec[histogram]
+	buckets []float64
+}
+
+type histogram struct {
+	counts []uint64 // per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+// NewHistogram registers a histogram with the given upper bucket bounds,
+// which must be sorted, and label names.
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	if !sort.Float64sAreSorted(buckets) {
+		panic("metrics: histogram buckets of " + name + " are not sorted")
+	}
+	h := &Histogram{vec: newVec[histogram](labels), buckets: buckets}
+	r.register(name, help, "histogram", h)
+	return h
+}
+
+// Observe records v for the label values.
+func (h *Histogram) Observe(v float64, values ...string) {
+	i := sort.SearchFloat64s(h.buckets, v) // first bucket with bound >= v
+	h.with(values, func() histogram { return histogram{counts: make([]uint64, len(h.buckets))} }, func(s *histogram) {
+		if i < len(s.counts) {
+			s.counts[i]++
+		}
+		s.count++
+		s.sum += v
+	})
+}
+
+func (h *Histogram) write(w *bufio.Writer, name string) {
+	h.each(func(labels string, s *histogram) {
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += s.counts[i]
+			writeSample(w, name+"_bucket", withLabel(labels, "le", formatFloat(bound)), float64(cumulative))
+		}
+		writeSample(w, name+"_bucket", withLabel(labels, "le", "+Inf"), float64(s.count))
+		writeSample(w, name+"_sum", labels, s.sum)
+		writeSample(w, name+"_count", labels, float64(s.count))
+	})
+}
+
+func zero() float64 { return 0 }
+
+func writeSample(w *bufio.Writer, name, labels string, v float64) {
+	w.WriteString(name)
+	w.WriteString(labels)
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(v))
+	w.WriteByte('\n')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// formatLabels renders {name="value",...}, or "" without labels.
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, n := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(n)
+		b.WriteString(`="`)
+		b.WriteString(labelEscaper.Replace(values[i]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// withLabel adds name="value" to labels rendered by formatLabels.
+func withLabel(labels, name, value string) string {
+	pair := name + `="` + labelEscaper.Replace(value) + `"`
+	if labels == "" {
+		return "{" + pair + "}"
+	}
+	return labels[:len(labels)-1] + "," + pair + "}"
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeHelp(s string) string { return helpEscaper.Replace(s) }
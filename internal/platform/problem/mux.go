@@ -0,0 +1,62 @@
+package problem
+
+import (
+	"bytes"
+	"net/http"
+)
+
+// Mux is an http.ServeMux that answers requests matching no route with
+// problems instead of ServeMux's plain-text "404 page not found" and "405
+// method not allowed". The Allow header of a 405 is kept.
+type Mux struct {
+	*http.ServeMux
+}
+
+// NewMux returns an empty Mux.
+func NewMux() *Mux {
+	return &Mux{http.NewServeMux()}
+}
+
+func (m *Mux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if _, pattern := m.Handler(r); pattern != "" {
+		m.ServeMux.ServeHTTP(w, r)
+		return
+	}
+	// Let ServeMux decide between 404, 405 (setting Allow) and a redirect
+	// to the clean path, then replace only the body of the errors.
+	u := &unmatched{header: w.Header()}
+	m.ServeMux.ServeHTTP(u, r)
+	switch u.status {
+	case http.StatusNotFound:
+		Error(w, r, http.StatusNotFound, CodeNotFound, "No resource exists at this path")
+	case http.StatusMethodNotAllowed:
+		Error(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed,
+			"Method "+r.Method+" is not allowed; allowed methods: "+w.Header().Get("Allow"))
+	default:
+		w.WriteHeader(u.status)
+		w.Write(u.body.Bytes())
+	}
+}
+
+// unmatched buffers ServeMux's response to a request no route matched. It
+// shares the real response's headers.
+type unmatched struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (u *unmatched) Header() http.Header { return u.header }
+
+func (u *unmatched) WriteHeader(status int) {
+	if u.status == 0 {
+		u.status = status
+	}
+}
+
+func (u *unmatched) Write(b []byte) (int, error) {
+	if u.status == 0 {
+		u.status = http.StatusOK
+	}
+	return u.body.Write(b)
+}
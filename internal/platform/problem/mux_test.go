@@ -0,0 +1,62 @@
+package problem
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMuxUnmatched(t *testing.T) {
+	mux := NewMux()
+	mux.HandleFunc("GET /items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("item " + r.PathValue("id")))
+	})
+	mux.HandleFunc("POST /items/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("GET /dir/", func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		method, target string
+		status         int
+		code           string
+		allow          string
+		location       string
+	}{
+		{method: "GET", target: "/items/1", status: http.StatusOK},
+		{method: "GET", target: "/nope", status: http.StatusNotFound, code: CodeNotFound},
+		{method: "DELETE", target: "/items/1", status: http.StatusMethodNotAllowed, code: CodeMethodNotAllowed, allow: "GET, HEAD, POST"},
+		// Redirected to the clean path; the status depends on the Go version.
+		{method: "GET", target: "/dir", location: "/dir/"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, nil))
+		if tt.status == 0 && w.Code/100 != 3 {
+			t.Errorf("%s %s: status %d, want a redirect", tt.method, tt.target, w.Code)
+			continue
+		}
+		if tt.status != 0 && w.Code != tt.status {
+			t.Errorf("%s %s: status %d, want %d", tt.method, tt.target, w.Code, tt.status)
+			continue
+		}
+		if got := w.Header().Get("Allow"); got != tt.allow {
+			t.Errorf("%s %s: Allow %q, want %q", tt.method, tt.target, got, tt.allow)
+		}
+		if got := w.Header().Get("Location"); got != tt.location {
+			t.Errorf("%s %s: Location %q, want %q", tt.method, tt.target, got, tt.location)
+		}
+		if tt.code == "" {
+			continue
+		}
+		if ct := w.Header().Get("Content-Type"); ct != ContentType {
+			t.Errorf("%s %s: Content-Type %q, want %q", tt.method, tt.target, ct, ContentType)
+		}
+		var p Problem
+		if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+			t.Fatalf("%s %s: %v: %s", tt.method, tt.target, err, w.Body)
+		}
+		if p.Code != tt.code || p.Status != tt.status || p.Instance != tt.target {
+			t.Errorf("%s %s: got problem %+v", tt.method, tt.target, p)
+		}
+	}
+}
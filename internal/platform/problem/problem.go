@@ -0,0 +1,160 @@
+// Package problem writes error responses as RFC 9457 problem details
+// (application/problem+json).
+//
+// Besides the standard members, every problem carries a stable,
+// machine-readable code that clients can switch on, the request ID, and for
+// validation failures a list of per-field errors:
+//
+//	{
+//	  "type": "urn:seattle-info:problem:validation_failed",
+//	  "title": "Bad Request",
+//	  "status": 400,
+//	  "detail": "The request body is invalid.",
+//	  "instance": "/api/v1/admin/categories",
+//	  "code": "validation_failed",
+//	  "request_id": "4f2c…",
+//	  "errors": [{"field": "name", "code": "required", "message": "name is required"}]
+//	}
+package problem
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"seattle-info-platform/internal/platform/requestid"
+)
+
+// ContentType is the media type of problem responses.
+const ContentType = "application/problem+json"
+
+// typePrefix turns a code into the problem type URI.
+const typePrefix = "urn:seattle-info:problem:"
+
+// Codes shared by all resources. Handlers define more specific ones.
+const (
+	CodeBadRequest       = "bad_request"
+	CodeInvalidBody      = "invalid_body"
+	CodeValidationFailed = "validation_failed"
+	CodeInvalidQuery     = "invalid_query_parameter"
+	CodeUnauthorized     = "unauthorized"
+	CodeForbidden        = "forbidden"
+	CodeNotFound         = "not_found"
+	CodeMethodNotAllowed = "method_not_allowed"
+	CodeConflict         = "conflict"
+	CodeInternal         = "internal_error"
+	CodeUnavailable      = "service_unavailable"
+)
+
+// FieldError describes one invalid field of a request.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Problem is a problem details object.
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      string       `json:"code"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+
+	// Extensions are extra members specific to the problem type, such as
+	// the statuses a resource may move to. They cannot override the
+	// standard members.
+	Extensions map[string]any `json:"-"`
+}
+
+// New returns a problem with the given HTTP status, code and human-readable
+// detail.
+func New(status int, code, detail string) *Problem {
+	return &Problem{
+		Type:   typePrefix + code,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// With sets an extension member and returns p.
+func (p *Problem) With(key string, value any) *Problem {
+	if p.Extensions == nil {
+		p.Extensions = make(map[string]any)
+	}
+	p.Extensions[key] = value
+	return p
+}
+
+// WithErrors appends field errors and returns p.
+func (p *Problem) WithErrors(errs ...FieldError) *Problem {
+	p.Errors = append(p.Errors, errs...)
+	return p
+}
+
+func (p *Problem) Error() string {
+	return p.Code + ": " + p.Detail
+}
+
+// MarshalJSON merges the extension members into the object.
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	type standard Problem
+	data, err := json.Marshal((*standard)(p))
+	if err != nil || len(p.Extensions) == 0 {
+		return data, err
+	}
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return nil, err
+	}
+	for k, v := range p.Extensions {
+		if _, taken := members[k]; taken {
+			continue
+		}
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		members[k] = raw
+	}
+	return json.Marshal(members)
+}
+
+// Write sends p as the response, filling in the instance and request ID
+// from r.
+func Write(w http.ResponseWriter, r *http.Request, p *Problem) {
+	if p.Instance == "" {
+		// r.RequestURI is the path as sent, before any StripPrefix.
+		p.Instance, _, _ = strings.Cut(r.RequestURI, "?")
+		if p.Instance == "" {
+			p.Instance = r.URL.Path
+		}
+	}
+	if p.RequestID == "" {
+		p.RequestID = requestid.FromContext(r.Context())
+	}
+	body, err := json.Marshal(p)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "encode problem", "code", p.Code, "err", err)
+		body = []byte(`{"type":"` + typePrefix + CodeInternal + `","title":"Internal Server Error","status":500,"code":"` + CodeInternal + `"}`)
+		p.Status = http.StatusInternalServerError
+	}
+	h := w.Header()
+	h.Del("Content-Length")
+	h.Set("Content-Type", ContentType)
+	h.Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	w.Write(append(body, '\n'))
+}
+
+// Error writes a problem without extensions or field errors. It is the
+// drop-in replacement for http.Error.
+func Error(w http.ResponseWriter, r *http.Request, status int, code, detail string) {
+	Write(w, r, New(status, code, detail))
+}
@@ -0,0 +1,61 @@
+package ratelimit
+
+import (
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"seattle-info-platform/internal/platform/problem"
+)
+
+// CodeRateLimited is the problem code of a refused request.
+const CodeRateLimited = "rate_limited"
+
+// Response headers, following the IETF RateLimit header fields draft.
+const (
+	HeaderLimit     = "RateLimit-Limit"
+	HeaderRemaining = "RateLimit-Remaining"
+	HeaderReset     = "RateLimit-Reset"
+)
+
+// Middleware throttles requests by the key that key returns for them. It
+// sets the RateLimit-* headers on every response and refuses requests over
+// the limit with 429 Too Many Requests and a Retry-After header. Requests
+// for which key returns "" are not limited, and a nil Limiter lets every
+// request through.
+func (l *Limiter) Middleware(key func(*http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if l == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			k := key(r)
+			if k == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			d := l.Allow(k)
+			h := w.Header()
+			h.Set(HeaderLimit, strconv.Itoa(d.Limit))
+			h.Set(HeaderRemaining, strconv.Itoa(d.Remaining))
+			h.Set(HeaderReset, strconv.Itoa(seconds(d.Reset)))
+			if !d.Allowed {
+				retry := max(seconds(d.RetryAfter), 1)
+				h.Set("Retry-After", strconv.Itoa(retry))
+				slog.InfoContext(r.Context(), "request rate limited", "key", k)
+				problem.Write(w, r, problem.New(http.StatusTooManyRequests, CodeRateLimited,
+					"Too many requests, please retry later").
+					With("retry_after_seconds", retry))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// seconds rounds d up to whole seconds, as the headers require.
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
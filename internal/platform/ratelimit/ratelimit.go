@@ -0,0 +1,115 @@
+// Package ratelimit throttles clients with token buckets.
+//
+// Each key, such as a user ID or client address, has a bucket holding up to
+// burst tokens that refills at rate tokens per second; a request spends one
+// token and is refused when none is left. Buckets live in memory and are
+// bounded: a bucket that has refilled completely is indistinguishable from a
+// new one and is dropped once more keys arrive, and beyond the key limit the
+// least recently seen key is forgotten.
+package ratelimit
+
+import (
+	"container/list"
+	"math"
+	"sync"
+	"time"
+)
+
+// Decision is the outcome of one request against a key's bucket.
+type Decision struct {
+	Allowed   bool
+	Limit     int           // bucket size
+	Remaining int           // whole tokens left after this request
+	Reset     time.Duration // until the bucket is full again
+	// RetryAfter is how long a refused client must wait for a token.
+	RetryAfter time.Duration
+}
+
+// Limiter holds one token bucket per key. It is safe for concurrent use.
+type Limiter struct {
+	rate    float64
+	burst   int
+	maxKeys int
+	now     func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*list.Element
+	recent  *list.List // of *bucket, most recently used first
+}
+
+type bucket struct {
+	key    string
+	tokens float64
+	last   time.Time
+}
+
+// New returns a Limiter allowing rate requests per second per key, in bursts
+// of up to burst, that tracks at most maxKeys keys.
+func New(rate float64, burst, maxKeys int) *Limiter {
+	return &Limiter{
+		rate:    rate,
+		burst:   burst,
+		maxKeys: maxKeys,
+		now:     time.Now,
+		buckets: make(map[string]*list.Element),
+		recent:  list.New(),
+	}
+}
+
+// Allow spends a token from key's bucket if it has one.
+func (l *Limiter) Allow(key string) Decision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	var b *bucket
+	if el, ok := l.buckets[key]; ok {
+		b = el.Value.(*bucket)
+		b.tokens = math.Min(float64(l.burst), b.tokens+now.Sub(b.last).Seconds()*l.rate)
+		l.recent.MoveToFront(el)
+	} else {
+		l.evict(now)
+		b = &bucket{key: key, tokens: float64(l.burst)}
+		l.buckets[key] = l.recent.PushFront(b)
+	}
+	b.last = now
+
+	d := Decision{Limit: l.burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		d.Allowed = true
+	} else {
+		d.RetryAfter = l.wait(1 - b.tokens)
+	}
+	d.Remaining = int(b.tokens)
+	d.Reset = l.wait(float64(l.burst) - b.tokens)
+	return d
+}
+
+// Len returns the number of keys tracked.
+func (l *Limiter) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.buckets)
+}
+
+// evict makes room for a new key. It drops the idle keys, whose buckets have
+// refilled, from the least recently used end, then, if the limiter is still
+// full, the least recently used keys. Both are amortised over insertions, so
+// no background sweeper is needed.
+func (l *Limiter) evict(now time.Time) {
+	for el := l.recent.Back(); el != nil; el = l.recent.Back() {
+		b := el.Value.(*bucket)
+		idle := b.tokens+now.Sub(b.last).Seconds()*l.rate >= float64(l.burst)
+		if !idle && len(l.buckets) < l.maxKeys {
+			return
+		}
+		l.recent.Remove(el)
+		delete(l.buckets, b.key)
+	}
+}
+
+// wait returns how long the bucket takes to gain tokens.
+func (l *Limiter) wait(tokens float64) time.Duration {
+	return time.Duration(tokens / l.rate * float64(time.Second))
+}
@@ -0,0 +1,121 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// clock is a fake time source the tests advance by hand.
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time          { return c.t }
+func (c *clock) advance(d time.Duration) { c.t = c.t.Add(d) }
+func newLimiter(rate float64, burst, maxKeys int) (*Limiter, *clock) {
+	c := &clock{t: time.Unix(1700000000, 0)}
+	l := New(rate, burst, maxKeys)
+	l.now = c.now
+	return l, c
+}
+
+func TestAllowBurstAndRefill(t *testing.T) {
+	l, c := newLimiter(2, 3, 10)
+	for i, want := range []int{2, 1, 0} {
+		d := l.Allow("a")
+		if !d.Allowed || d.Remaining != want || d.Limit != 3 {
+			t.Fatalf("request %d = %+v, want allowed with %d remaining", i, d, want)
+		}
+	}
+	d := l.Allow("a")
+	if d.Allowed {
+		t.Fatalf("request over the burst allowed: %+v", d)
+	}
+	if d.RetryAfter != 500*time.Millisecond || d.Reset != 1500*time.Millisecond {
+		t.Errorf("refused = %+v, want RetryAfter 500ms and Reset 1.5s", d)
+	}
+	if d := l.Allow("b"); !d.Allowed {
+		t.Errorf("another key shares the bucket: %+v", d)
+	}
+
+	c.advance(500 * time.Millisecond)
+	if d := l.Allow("a"); !d.Allowed || d.Remaining != 0 {
+		t.Errorf("after one token refilled = %+v, want allowed with 0 remaining", d)
+	}
+	c.advance(time.Hour)
+	if d := l.Allow("a"); !d.Allowed || d.Remaining != 2 {
+		t.Errorf("after a long pause = %+v, want the bucket capped at the burst", d)
+	}
+}
+
+func TestEviction(t *testing.T) {
+	l, c := newLimiter(1, 2, 2)
+	l.Allow("a")
+	l.Allow("a")
+	l.Allow("b")
+	l.Allow("b")
+	l.Allow("a") // a is now the most recently used
+	l.Allow("c") // full: b, the least recently used, goes
+	if n := l.Len(); n != 2 {
+		t.Fatalf("Len = %d, want 2", n)
+	}
+	if d := l.Allow("a"); d.Allowed {
+		t.Errorf("a was evicted instead of b: %+v", d)
+	}
+	if d := l.Allow("b"); !d.Allowed || d.Remaining != 1 {
+		t.Errorf("b kept its spent bucket: %+v", d)
+	}
+
+	// Once every bucket has refilled, new keys drop the idle ones first.
+	c.advance(time.Minute)
+	l.Allow("d")
+	if n := l.Len(); n != 1 {
+		t.Errorf("Len = %d after idle keys, want 1", n)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	l, _ := newLimiter(1, 1, 10)
+	h := l.Middleware(func(r *http.Request) string {
+		return r.Header.Get("X-Key")
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	serve := func(key string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("X-Key", key)
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	w := serve("a")
+	if w.Code != http.StatusOK || w.Header().Get(HeaderLimit) != "1" || w.Header().Get(HeaderRemaining) != "0" {
+		t.Errorf("first request = %d %v", w.Code, w.Header())
+	}
+	w = serve("a")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request status = %d, want 429", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("Retry-After = %q, want 1", got)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Errorf("Content-Type = %q, want application/problem+json", ct)
+	}
+
+	for i := 0; i < 3; i++ {
+		if w := serve(""); w.Code != http.StatusOK || w.Header().Get(HeaderLimit) != "" {
+			t.Errorf("unkeyed request = %d %v, want unlimited", w.Code, w.Header())
+		}
+	}
+
+	var off *Limiter
+	h = off.Middleware(func(*http.Request) string { return "a" })(http.NotFoundHandler())
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("nil Limiter request %d status = %d, want the handler's 404", i, w.Code)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package requestid
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		sent  string
+		reuse bool
+	}{
+		{"none", "", false},
+		{"well formed", "lb-1234/abc", true},
+		{"with space", "a b", false},
+		{"with newline", "a\nrequest_id=forged", false},
+		{"non-ASCII", "café", false},
+		{"too long", strings.Repeat("a", maxLen+1), false},
+		{"longest", strings.Repeat("a", maxLen), true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var inContext string
+			h := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				inContext = FromContext(r.Context())
+			}))
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.sent != "" {
+				r.Header.Set(Header, tc.sent)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			id := w.Header().Get(Header)
+			if id != inContext {
+				t.Errorf("response ID %q differs from the context's %q", id, inContext)
+			}
+			if tc.reuse && id != tc.sent {
+				t.Errorf("ID = %q, want the client's %q", id, tc.sent)
+			}
+			if !tc.reuse && (id == tc.sent || len(id) != 32) {
+				t.Errorf("ID = %q, want a new 32-character ID", id)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package search
+
+import (
+	"html"
+	"strings"
+)
+
+// snippetWords is the number of words in a snippet of a long text.
+const snippetWords = 30
+
+// highlightText marks the words of text whose stem is in stems. Texts longer
+// than snippetWords are cut to the window with the most matches. ok is false
+// if nothing matched.
+func highlightText(text string, stems map[string]bool) (snippet string, ok bool) {
+	tokens := tokenize(text)
+	match := make([]bool, len(tokens))
+	count := 0
+	for i, t := range tokens {
+		if stems[Stem(t.word)] {
+			match[i] = true
+			count++
+		}
+	}
+	if count == 0 {
+		return "", false
+	}
+
+	from, to := 0, len(tokens)
+	if len(tokens) > snippetWords {
+		best, inWindow := 0, 0
+		for i := range tokens {
+			if match[i] {
+				inWindow++
+			}
+			if i >= snippetWords && match[i-snippetWords] {
+				inWindow--
+			}
+			if i >= snippetWords-1 && inWindow > best {
+				best, from = inWindow, i-snippetWords+1
+			}
+		}
+		to = from + snippetWords
+	}
+
+	var b strings.Builder
+	start := 0 // byte offset of the snippet in text
+	if from > 0 {
+		b.WriteString("…")
+		start = tokens[from].start
+	}
+	pos := start
+	for i := from; i < to; i++ {
+		t := tokens[i]
+		b.WriteString(html.EscapeString(text[pos:t.start]))
+		if match[i] {
+			b.WriteString("<mark>" + html.EscapeString(text[t.start:t.end]) + "</mark>")
+		} else {
+			b.WriteString(html.EscapeString(text[t.start:t.end]))
+		}
+		pos = t.end
+	}
+	if to < len(tokens) {
+		b.WriteString("…")
+	} else {
+		b.WriteString(html.EscapeString(text[pos:]))
+	}
+	return b.String(), true
+}
@@ -0,0 +1,254 @@
+package search
+
+import (
+	"math"
+	"slices"
+	"strings"
+)
+
+// BM25 parameters.
+const (
+	k1 = 1.2
+	b  = 0.75
+)
+
+// Field is one searchable text of a document. Weight scales how much a
+// match in the field counts towards relevance.
+type Field struct {
+	Name   string
+	Weight float64
+}
+
+// Hit is a document matching a query.
+type Hit struct {
+	ID    string
+	Score float64
+	// Highlights maps field names to snippets of the field's text with the
+	// matching words wrapped in <mark> tags. The rest of the text is HTML
+	// escaped. Fields without matches are omitted.
+	Highlights map[string]string
+}
+
+// Index is an inverted index over documents made of the fields given to
+// NewIndex.
+type Index struct {
+	fields   []Field
+	docs     map[string]*document
+	postings map[string]map[string]*posting // stem → document ID → positions
+	words    []string                       // sorted vocabulary, for prefix clauses
+	wordRefs map[string]int                 // documents using each word
+	totalLen float64                        // sum of weighted document lengths
+}
+
+type document struct {
+	texts  []string
+	length float64 // weighted number of words
+	stems  []string
+	words  []string
+}
+
+// posting holds the word positions of a stem in a document, per field.
+type posting struct {
+	positions [][]int
+}
+
+// NewIndex returns an empty index over documents with the given fields.
+func NewIndex(fields ...Field) *Index {
+	return &Index{
+		fields:   fields,
+		docs:     make(map[string]*document),
+		postings: make(map[string]map[string]*posting),
+		wordRefs: make(map[string]int),
+	}
+}
+
+// Len returns the number of documents in the index.
+func (x *Index) Len() int { return len(x.docs) }
+
+// Add indexes a document, replacing any previous version with the same ID.
+// texts holds one value per field, in the order given to NewIndex.
+func (x *Index) Add(id string, texts ...string) {
+	x.Remove(id)
+	doc := &document{texts: slices.Clone(texts)}
+	stems := make(map[string]bool)
+	words := make(map[string]bool)
+	for f, text := range texts {
+		tokens := tokenize(text)
+		doc.length += x.fields[f].Weight * float64(len(tokens))
+		for pos, t := range tokens {
+			stem := Stem(t.word)
+			docs := x.postings[stem]
+			if docs == nil {
+				docs = make(map[string]*posting)
+				x.postings[stem] = docs
+			}
+			p := docs[id]
+			if p == nil {
+				p = &posting{positions: make([][]int, len(x.fields))}
+				docs[id] = p
+			}
+			p.positions[f] = append(p.positions[f], pos)
+			stems[stem] = true
+			words[t.word] = true
+		}
+	}
+	for stem := range stems {
+		doc.stems = append(doc.stems, stem)
+	}
+	for word := range words {
+		doc.words = append(doc.words, word)
+		if x.wordRefs[word]++; x.wordRefs[word] == 1 {
+			i, _ := slices.BinarySearch(x.words, word)
+			x.words = slices.Insert(x.words, i, word)
+		}
+	}
+	x.docs[id] = doc
+	x.totalLen += doc.length
+}
+
+// Remove drops a document from the index. Unknown IDs are ignored.
+func (x *Index) Remove(id string) {
+	doc, ok := x.docs[id]
+	if !ok {
+		return
+	}
+	for _, stem := range doc.stems {
+		delete(x.postings[stem], id)
+		if len(x.postings[stem]) == 0 {
+			delete(x.postings, stem)
+		}
+	}
+	for _, word := range doc.words {
+		if x.wordRefs[word]--; x.wordRefs[word] == 0 {
+			delete(x.wordRefs, word)
+			if i, ok := slices.BinarySearch(x.words, word); ok {
+				x.words = slices.Delete(x.words, i, i+1)
+			}
+		}
+	}
+	delete(x.docs, id)
+	x.totalLen -= doc.length
+}
+
+// Search returns the documents matching q, most relevant first.
+func (x *Index) Search(q *Query) []Hit {
+	if len(x.docs) == 0 {
+		return nil
+	}
+	avgLen := x.totalLen / float64(len(x.docs))
+	var scores map[string]float64
+	var highlight []map[string]bool // per field, the stems to mark
+	for range x.fields {
+		highlight = append(highlight, make(map[string]bool))
+	}
+	for _, c := range q.clauses {
+		tf, stems := x.match(c)
+		next := make(map[string]float64, len(tf))
+		idf := math.Log(1 + (float64(len(x.docs))-float64(len(tf))+0.5)/(float64(len(tf))+0.5))
+		for id, freq := range tf {
+			prev, ok := scores[id]
+			if scores != nil && !ok {
+				continue // Failed an earlier clause.
+			}
+			norm := k1 * (1 - b + b*x.docs[id].length/avgLen)
+			next[id] = prev + idf*freq*(k1+1)/(freq+norm)
+		}
+		scores = next
+		for _, stem := range stems {
+			for f := range x.fields {
+				highlight[f][stem] = true
+			}
+		}
+		if len(scores) == 0 {
+			return nil
+		}
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for id, score := range scores {
+		hit := Hit{ID: id, Score: score, Highlights: make(map[string]string)}
+		for f, field := range x.fields {
+			if snippet, ok := highlightText(x.docs[id].texts[f], highlight[f]); ok {
+				hit.Highlights[field.Name] = snippet
+			}
+		}
+		hits = append(hits, hit)
+	}
+	slices.SortFunc(hits, func(a, b Hit) int {
+		if a.Score != b.Score {
+			if a.Score > b.Score {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return hits
+}
+
+// match returns the weighted frequency of c in every document containing it
+// and the stems that make up the match.
+func (x *Index) match(c clause) (tf map[string]float64, stems []string) {
+	tf = make(map[string]float64)
+	switch {
+	case c.prefix != "":
+		seen := make(map[string]bool)
+		start, _ := slices.BinarySearch(x.words, c.prefix)
+		for _, word := range x.words[start:] {
+			if !strings.HasPrefix(word, c.prefix) {
+				break
+			}
+			if stem := Stem(word); !seen[stem] {
+				seen[stem] = true
+				stems = append(stems, stem)
+			}
+		}
+		// A word can share its stem with a word outside the prefix
+		// ("run*" finds "running" whose stem "run" also covers "runs"),
+		// which is the point of stemming.
+		for _, stem := range stems {
+			for id, p := range x.postings[stem] {
+				tf[id] += x.weighted(p, nil)
+			}
+		}
+	case len(c.terms) == 1:
+		for id, p := range x.postings[c.terms[0]] {
+			tf[id] = x.weighted(p, nil)
+		}
+		stems = c.terms
+	default:
+		first := x.postings[c.terms[0]]
+	docs:
+		for id, p := range first {
+			rest := make([]*posting, len(c.terms)-1)
+			for i, term := range c.terms[1:] {
+				if rest[i] = x.postings[term][id]; rest[i] == nil {
+					continue docs
+				}
+			}
+			if freq := x.weighted(p, rest); freq > 0 {
+				tf[id] = freq
+			}
+		}
+		stems = c.terms
+	}
+	return tf, stems
+}
+
+// weighted returns the weighted number of occurrences of the phrase that
+// starts with p and continues with rest, in order and without gaps.
+func (x *Index) weighted(p *posting, rest []*posting) float64 {
+	var freq float64
+	for f, positions := range p.positions {
+	next:
+		for _, pos := range positions {
+			for i, r := range rest {
+				if _, ok := slices.BinarySearch(r.positions[f], pos+i+1); !ok {
+					continue next
+				}
+			}
+			freq += x.fields[f].Weight
+		}
+	}
+	return freq
+}
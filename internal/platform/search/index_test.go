@@ -0,0 +1,129 @@
+package search
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func newTestIndex() *Index {
+	x := NewIndex(Field{Name: "title", Weight: 2}, Field{Name: "body", Weight: 1})
+	x.Add("bike", "Road bike for sale", "Lightly used road bike, new tires.")
+	x.Add("tires", "Winter tires", "Four studded tires, one season old. Bike rack included.")
+	x.Add("scam", "Wire transfer scam warning", "Never pay by wire transfer before seeing the item.")
+	x.Add("transfer", "Transferring apartment lease", "Lease transfer in Capitol Hill; wire the deposit to the landlord.")
+	x.Add("html", "Desk <b>bargain</b>", `Solid oak desk & chair, "as is".`)
+	return x
+}
+
+func search(t *testing.T, x *Index, q string) []string {
+	t.Helper()
+	query, err := ParseQuery(q)
+	if err != nil {
+		t.Fatalf("ParseQuery(%q): %v", q, err)
+	}
+	var ids []string
+	for _, h := range x.Search(query) {
+		ids = append(ids, h.ID)
+	}
+	return ids
+}
+
+func TestSearch(t *testing.T) {
+	x := newTestIndex()
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		// A title match outweighs a body match, and two mentions one.
+		{"bike", []string{"bike", "tires"}},
+		// Stems match other forms of the word.
+		{"tire", []string{"tires", "bike"}},
+		{"scams", []string{"scam"}},
+		// Every clause must match.
+		{"bike rack", []string{"tires"}},
+		{"wire transfer", []string{"scam", "transfer"}},
+		// A phrase needs its words next to each other and in order.
+		{`"wire transfer"`, []string{"scam"}},
+		{`"transfer wire"`, nil},
+		// A word with inner punctuation is a phrase too.
+		{"capitol-hill", []string{"transfer"}},
+		// Prefixes match any word that starts with them. Both match twice;
+		// the shorter document ranks first.
+		{"transf*", []string{"scam", "transfer"}},
+		{"lea* transf*", []string{"transfer"}},
+		{"nothing", nil},
+	}
+	for _, tt := range tests {
+		if got := search(t, x, tt.query); !slices.Equal(got, tt.want) {
+			t.Errorf("search %q = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestSearchAfterUpdate(t *testing.T) {
+	x := newTestIndex()
+	x.Add("bike", "Mountain bicycle", "Full suspension.")
+	if got := search(t, x, "road"); got != nil {
+		t.Errorf("replaced text still found: %v", got)
+	}
+	if got := search(t, x, "mountain"); !slices.Equal(got, []string{"bike"}) {
+		t.Errorf("new text: %v", got)
+	}
+	x.Remove("tires")
+	x.Remove("unknown")
+	if got := search(t, x, "studded"); got != nil {
+		t.Errorf("removed document still found: %v", got)
+	}
+	// Words only the removed document used no longer match prefixes.
+	if got := search(t, x, "stud*"); got != nil {
+		t.Errorf("removed word still matches a prefix: %v", got)
+	}
+	if x.Len() != 4 {
+		t.Errorf("Len = %d, want 4", x.Len())
+	}
+}
+
+func TestHighlights(t *testing.T) {
+	x := newTestIndex()
+	query, _ := ParseQuery("desk")
+	hits := x.Search(query)
+	if len(hits) != 1 {
+		t.Fatalf("got %d hits", len(hits))
+	}
+	want := map[string]string{
+		"title": "<mark>Desk</mark> &lt;b&gt;bargain&lt;/b&gt;",
+		"body":  "Solid oak <mark>desk</mark> &amp; chair, &#34;as is&#34;.",
+	}
+	for field, snippet := range want {
+		if got := hits[0].Highlights[field]; got != snippet {
+			t.Errorf("%s highlight = %q, want %q", field, got, snippet)
+		}
+	}
+
+	// Fields without a match have no highlight.
+	query, _ = ParseQuery("bargain")
+	if hl := x.Search(query)[0].Highlights; hl["body"] != "" || hl["title"] == "" {
+		t.Errorf("highlights %v", hl)
+	}
+
+	// A long text is cut to the window with the most matches.
+	long := "start"
+	for i := 0; i < 50; i++ {
+		long += " filler"
+	}
+	long += " match here at the end"
+	snippet, ok := highlightText(long, map[string]bool{Stem("match"): true})
+	if !ok || !strings.HasPrefix(snippet, "…") || !strings.Contains(snippet, "<mark>match</mark>") || strings.Contains(snippet, "start") {
+		t.Errorf("snippet %q", snippet)
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	for _, q := range []string{"", "   ", `"unterminated`, "a*", "two words*x*", `"" ...`} {
+		if _, err := ParseQuery(q); !errors.Is(err, ErrInvalidQuery) {
+			t.Errorf("ParseQuery(%q) = %v, want ErrInvalidQuery", q, err)
+		}
+	}
+}
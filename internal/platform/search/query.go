@@ -0,0 +1,76 @@
+package search
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidQuery is returned by ParseQuery for malformed queries.
+var ErrInvalidQuery = errors.New("search: invalid query")
+
+// minPrefix is the shortest prefix a prefix clause may use.
+const minPrefix = 2
+
+// Query is a parsed search query. Every clause must match.
+type Query struct {
+	clauses []clause
+}
+
+// clause matches a single stemmed term, a phrase of consecutive terms, or
+// any word starting with prefix.
+type clause struct {
+	terms  []string
+	prefix string
+}
+
+// ParseQuery parses a query string. See the package documentation for the
+// syntax.
+func ParseQuery(s string) (*Query, error) {
+	q := &Query{}
+	rest := s
+	for {
+		rest = strings.TrimSpace(rest)
+		if rest == "" {
+			break
+		}
+		if rest[0] == '"' {
+			end := strings.IndexByte(rest[1:], '"')
+			if end < 0 {
+				return nil, fmt.Errorf("%w: unterminated quote", ErrInvalidQuery)
+			}
+			phrase := rest[1 : end+1]
+			rest = rest[end+2:]
+			var terms []string
+			for _, t := range tokenize(phrase) {
+				terms = append(terms, Stem(t.word))
+			}
+			if len(terms) > 0 {
+				q.clauses = append(q.clauses, clause{terms: terms})
+			}
+			continue
+		}
+		word, tail, _ := strings.Cut(rest, " ")
+		rest = tail
+		if base, ok := strings.CutSuffix(word, "*"); ok {
+			tokens := tokenize(base)
+			if len(tokens) != 1 || len(tokens[0].word) < minPrefix {
+				return nil, fmt.Errorf("%w: prefix %q must be a single word of at least %d characters", ErrInvalidQuery, word, minPrefix)
+			}
+			q.clauses = append(q.clauses, clause{prefix: tokens[0].word})
+			continue
+		}
+		// A word with inner punctuation, such as "e-mail", is a phrase.
+		var terms []string
+		for _, t := range tokenize(word) {
+			terms = append(terms, Stem(t.word))
+		}
+		if len(terms) > 0 {
+			q.clauses = append(q.clauses, clause{terms: terms})
+		}
+	}
+	if len(q.clauses) == 0 {
+		return nil, fmt.Errorf("%w: no words to search for", ErrInvalidQuery)
+	}
+	return q, nil
+}
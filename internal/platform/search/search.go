@@ -0,0 +1,61 @@
+// Package search is an in-memory full-text index with BM25 relevance
+// ranking.
+//
+// Text is split into lowercase words, which are indexed by their English
+// stem, so "scams" also finds "scam" and "scamming". A query is a list of
+// clauses that must all match:
+//
+//	wire transfer       both words, anywhere
+//	"wire transfer"     the words next to each other, in order
+//	transf*             any word starting with transf
+//
+// An Index does no locking of its own; callers must serialize writes and
+// must not read while writing.
+package search
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// token is one word of a text: its lowercase form and the byte range it
+// occupies in the original.
+type token struct {
+	word       string
+	start, end int
+}
+
+// tokenize splits text into words. A word is a run of letters and digits;
+// apostrophes inside a word are dropped, so "don't" becomes "dont".
+func tokenize(text string) []token {
+	var tokens []token
+	var b strings.Builder
+	start := -1
+	flush := func(end int) {
+		if start >= 0 {
+			tokens = append(tokens, token{word: b.String(), start: start, end: end})
+			b.Reset()
+			start = -1
+		}
+	}
+	for i, r := range text {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if start < 0 {
+				start = i
+			}
+			b.WriteRune(unicode.ToLower(r))
+		case (r == '\'' || r == '’') && start >= 0 && i+utf8.RuneLen(r) < len(text):
+			// Keep going if a letter follows; the apostrophe itself is dropped.
+			next, _ := utf8.DecodeRuneInString(text[i+utf8.RuneLen(r):])
+			if !unicode.IsLetter(next) {
+				flush(i)
+			}
+		default:
+			flush(i)
+		}
+	}
+	flush(len(text))
+	return tokens
+}
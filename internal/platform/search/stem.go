@@ -0,0 +1,223 @@
+package search
+
+// Stem reduces an English word to its stem with the Porter algorithm
+// ("connections" → "connect", "scamming" → "scam"). word must be lowercase;
+// words of two letters or less, or containing anything but ASCII letters,
+// are returned unchanged.
+func Stem(word string) string {
+	if len(word) <= 2 {
+		return word
+	}
+	for i := 0; i < len(word); i++ {
+		if word[i] < 'a' || word[i] > 'z' {
+			return word
+		}
+	}
+	s := stemmer{b: []byte(word)}
+	s.step1a()
+	s.step1b()
+	s.step1c()
+	s.step2()
+	s.step3()
+	s.step4()
+	s.step5()
+	return string(s.b)
+}
+
+type stemmer struct {
+	b []byte
+}
+
+// cons reports whether b[i] is a consonant. Y is a consonant at the start of
+// a word or after a vowel.
+func (s *stemmer) cons(i int) bool {
+	switch s.b[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		return i == 0 || !s.cons(i-1)
+	}
+	return true
+}
+
+// measure returns m, the number of vowel-consonant sequences in b[:n].
+func (s *stemmer) measure(n int) int {
+	m, i := 0, 0
+	for i < n && s.cons(i) {
+		i++
+	}
+	for i < n {
+		for i < n && !s.cons(i) {
+			i++
+		}
+		if i == n {
+			break
+		}
+		m++
+		for i < n && s.cons(i) {
+			i++
+		}
+	}
+	return m
+}
+
+// hasVowel reports whether b[:n] contains a vowel.
+func (s *stemmer) hasVowel(n int) bool {
+	for i := 0; i < n; i++ {
+		if !s.cons(i) {
+			return true
+		}
+	}
+	return false
+}
+
+// doubleCons reports whether b[:n] ends with a double consonant.
+func (s *stemmer) doubleCons(n int) bool {
+	return n >= 2 && s.b[n-1] == s.b[n-2] && s.cons(n-1)
+}
+
+// cvc reports whether b[:n] ends consonant-vowel-consonant with the last
+// consonant not w, x or y, as in "hop" but not "snow".
+func (s *stemmer) cvc(n int) bool {
+	if n < 3 || !s.cons(n-1) || s.cons(n-2) || !s.cons(n-3) {
+		return false
+	}
+	switch s.b[n-1] {
+	case 'w', 'x', 'y':
+		return false
+	}
+	return true
+}
+
+func (s *stemmer) ends(suffix string) bool {
+	n := len(s.b)
+	return n >= len(suffix) && string(s.b[n-len(suffix):]) == suffix
+}
+
+// stemLen returns the length of b without suffix.
+func (s *stemmer) stemLen(suffix string) int {
+	return len(s.b) - len(suffix)
+}
+
+func (s *stemmer) replace(suffix, with string) {
+	s.b = append(s.b[:s.stemLen(suffix)], with...)
+}
+
+// replaceLongest replaces the longest suffix of b found in rules if the
+// remaining stem has a measure above min. Only the longest match is tried.
+func (s *stemmer) replaceLongest(rules [][2]string, min int) {
+	best := -1
+	for i, r := range rules {
+		if s.ends(r[0]) && (best < 0 || len(r[0]) > len(rules[best][0])) {
+			best = i
+		}
+	}
+	if best >= 0 && s.measure(s.stemLen(rules[best][0])) > min {
+		s.replace(rules[best][0], rules[best][1])
+	}
+}
+
+func (s *stemmer) step1a() {
+	switch {
+	case s.ends("sses"):
+		s.replace("sses", "ss")
+	case s.ends("ies"):
+		s.replace("ies", "i")
+	case s.ends("ss"):
+	case s.ends("s"):
+		s.replace("s", "")
+	}
+}
+
+func (s *stemmer) step1b() {
+	if s.ends("eed") {
+		if s.measure(s.stemLen("eed")) > 0 {
+			s.replace("eed", "ee")
+		}
+		return
+	}
+	var suffix string
+	switch {
+	case s.ends("ed"):
+		suffix = "ed"
+	case s.ends("ing"):
+		suffix = "ing"
+	default:
+		return
+	}
+	if !s.hasVowel(s.stemLen(suffix)) {
+		return
+	}
+	s.replace(suffix, "")
+	n := len(s.b)
+	switch {
+	case s.ends("at"), s.ends("bl"), s.ends("iz"):
+		s.b = append(s.b, 'e')
+	case s.doubleCons(n):
+		if c := s.b[n-1]; c != 'l' && c != 's' && c != 'z' {
+			s.b = s.b[:n-1]
+		}
+	case s.measure(n) == 1 && s.cvc(n):
+		s.b = append(s.b, 'e')
+	}
+}
+
+func (s *stemmer) step1c() {
+	if s.ends("y") && s.hasVowel(s.stemLen("y")) {
+		s.replace("y", "i")
+	}
+}
+
+var step2Rules = [][2]string{
+	{"ational", "ate"}, {"tional", "tion"}, {"enci", "ence"}, {"anci", "ance"},
+	{"izer", "ize"}, {"bli", "ble"}, {"alli", "al"}, {"entli", "ent"},
+	{"eli", "e"}, {"ousli", "ous"}, {"ization", "ize"}, {"ation", "ate"},
+	{"ator", "ate"}, {"alism", "al"}, {"iveness", "ive"}, {"fulness", "ful"},
+	{"ousness", "ous"}, {"aliti", "al"}, {"iviti", "ive"}, {"biliti", "ble"},
+	{"logi", "log"},
+}
+
+func (s *stemmer) step2() { s.replaceLongest(step2Rules, 0) }
+
+var step3Rules = [][2]string{
+	{"icate", "ic"}, {"ative", ""}, {"alize", "al"}, {"iciti", "ic"},
+	{"ical", "ic"}, {"ful", ""}, {"ness", ""},
+}
+
+func (s *stemmer) step3() { s.replaceLongest(step3Rules, 0) }
+
+var step4Suffixes = []string{
+	"al", "ance", "ence", "er", "ic", "able", "ible", "ant", "ement", "ment",
+	"ent", "ion", "ou", "ism", "ate", "iti", "ous", "ive", "ize",
+}
+
+func (s *stemmer) step4() {
+	best := ""
+	for _, suffix := range step4Suffixes {
+		if s.ends(suffix) && len(suffix) > len(best) {
+			best = suffix
+		}
+	}
+	if best == "" {
+		return
+	}
+	n := s.stemLen(best)
+	if best == "ion" && (n == 0 || (s.b[n-1] != 's' && s.b[n-1] != 't')) {
+		return
+	}
+	if s.measure(n) > 1 {
+		s.b = s.b[:n]
+	}
+}
+
+func (s *stemmer) step5() {
+	if s.ends("e") {
+		n := s.stemLen("e")
+		if m := s.measure(n); m > 1 || (m == 1 && !s.cvc(n)) {
+			s.b = s.b[:n]
+		}
+	}
+	if n := len(s.b); s.b[n-1] == 'l' && s.doubleCons(n) && s.measure(n) > 1 {
+		s.b = s.b[:n-1]
+	}
+}
@@ -0,0 +1,45 @@
+package securityheaders
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMiddleware(t *testing.T) {
+	page := CSP("default-src 'self'")(http.NotFoundHandler())
+	h := Middleware(Options{HSTSMaxAge: 365 * 24 * time.Hour, CSP: APIPolicy})
+	for _, tc := range []struct {
+		name    string
+		handler http.Handler
+		csp     string
+	}{
+		{"api", http.NotFoundHandler(), APIPolicy},
+		{"page", page, "default-src 'self'"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h(tc.handler).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			for name, want := range map[string]string{
+				"X-Content-Type-Options":    "nosniff",
+				"X-Frame-Options":           "DENY",
+				"Referrer-Policy":           "strict-origin-when-cross-origin",
+				"Strict-Transport-Security": "max-age=31536000",
+				"Content-Security-Policy":   tc.csp,
+			} {
+				if got := w.Header().Get(name); got != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	Middleware(Options{})(http.NotFoundHandler()).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	for _, name := range []string{"Strict-Transport-Security", "Content-Security-Policy"} {
+		if got := w.Header().Get(name); got != "" {
+			t.Errorf("%s = %q with zero Options", name, got)
+		}
+	}
+}
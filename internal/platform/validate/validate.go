@@ -0,0 +1,370 @@
+// Package validate decodes JSON request bodies and checks them against
+// struct tag rules, reporting every violation at once as a problem.
+//
+// Rules are listed, comma separated, in a `validate` tag:
+//
+//	required    the field must be present and not blank
+//	min=N       a string must have at least N characters, ignoring
+//	            leading and trailing space
+//	max=N       a string must have at most N characters, likewise
+//	enum=a|b|c  a string must be one of the listed values
+//	email       a string must be an email address
+//
+// Fields whose type has an IsValid() bool method, such as user.UserRole, are
+// also checked with it. Omitted optional fields skip every rule; a pointer
+// field is omitted only when nil. Errors name fields by their JSON names.
+//
+// Tags are parsed once per type. Pass every request type to Must at init so
+// that a malformed rule stops the server at startup.
+package validate
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/mail"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"seattle-info-platform/internal/platform/problem"
+)
+
+// MaxBodyBytes is the largest request body DecodeJSON accepts.
+const MaxBodyBytes = 1 << 20
+
+// Field error codes.
+const (
+	CodeRequired     = "required"
+	CodeTooShort     = "too_short"
+	CodeTooLong      = "too_long"
+	CodeNotAllowed   = "not_allowed" // not in enum
+	CodeInvalid      = "invalid"     // rejected by IsValid
+	CodeInvalidEmail = "invalid_email"
+	CodeUnknownField = "unknown_field"
+	CodeWrongType    = "wrong_type"
+)
+
+// Problem codes beyond the generic ones in package problem.
+const (
+	CodeUnsupportedMediaType = "unsupported_media_type"
+	CodeBodyTooLarge         = "body_too_large"
+)
+
+type validator interface {
+	IsValid() bool
+}
+
+// DecodeJSON reads the body of r into dst, a pointer to a struct, and
+// validates it with Struct. The body must be a single JSON object of at most
+// MaxBodyBytes, sent as application/json if it names a content type, with no
+// fields dst does not declare. A non-nil result is ready to be written.
+func DecodeJSON(w http.ResponseWriter, r *http.Request, dst any) *problem.Problem {
+	return decode(w, r, dst, false)
+}
+
+// DecodeOptionalJSON is DecodeJSON for bodies that may be omitted. An empty
+// body, however it was sent, leaves dst untouched and is validated as such.
+func DecodeOptionalJSON(w http.ResponseWriter, r *http.Request, dst any) *problem.Problem {
+	return decode(w, r, dst, true)
+}
+
+func decode(w http.ResponseWriter, r *http.Request, dst any, optional bool) *problem.Problem {
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		mediaType, _, err := mime.ParseMediaType(ct)
+		if err != nil || (mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json")) {
+			return problem.New(http.StatusUnsupportedMediaType, CodeUnsupportedMediaType,
+				"Request body must be application/json")
+		}
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxBodyBytes))
+	if err != nil {
+		return decodeProblem(err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	switch err := dec.Decode(dst); {
+	case err == io.EOF && optional:
+	case err != nil:
+		return decodeProblem(err)
+	default:
+		if _, err := dec.Token(); err != io.EOF {
+			return problem.New(http.StatusBadRequest, problem.CodeInvalidBody,
+				"Request body must contain a single JSON object")
+		}
+	}
+	// Unknown fields are collected rather than rejected by the decoder, so
+	// they are reported together with every other violation.
+	violations, err := Struct(dst)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "validate request body", "err", err)
+		return problem.New(http.StatusInternalServerError, problem.CodeInternal, "The request could not be validated")
+	}
+	errs := append(unknownFields(body, dst), violations...)
+	if len(errs) > 0 {
+		return Problem(errs)
+	}
+	return nil
+}
+
+// unknownFields reports the top-level members of the JSON object body that
+// dst does not declare. Like encoding/json, it matches names ignoring case.
+func unknownFields(body []byte, dst any) []problem.FieldError {
+	var members map[string]json.RawMessage
+	if json.Unmarshal(body, &members) != nil {
+		return nil
+	}
+	rt := reflect.Indirect(reflect.ValueOf(dst)).Type()
+	names := make([]string, 0, len(members))
+	for name := range members {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	var errs []problem.FieldError
+	for _, name := range names {
+		known := false
+		for i := 0; i < rt.NumField() && !known; i++ {
+			f := rt.Field(i)
+			known = f.IsExported() && strings.EqualFold(jsonName(f), name)
+		}
+		if !known {
+			errs = append(errs, problem.FieldError{Field: name, Code: CodeUnknownField, Message: name + " is not a known field"})
+		}
+	}
+	return errs
+}
+
+// Problem wraps field errors in a validation_failed problem.
+func Problem(errs []problem.FieldError) *problem.Problem {
+	detail := "The request has 1 invalid field"
+	if len(errs) != 1 {
+		detail = fmt.Sprintf("The request has %d invalid fields", len(errs))
+	}
+	return problem.New(http.StatusBadRequest, problem.CodeValidationFailed, detail).WithErrors(errs...)
+}
+
+func decodeProblem(err error) *problem.Problem {
+	var (
+		maxBytes  *http.MaxBytesError
+		syntax    *json.SyntaxError
+		wrongType *json.UnmarshalTypeError
+	)
+	switch {
+	case errors.As(err, &maxBytes):
+		return problem.New(http.StatusRequestEntityTooLarge, CodeBodyTooLarge,
+			fmt.Sprintf("Request body must not exceed %d bytes", maxBytes.Limit))
+	case errors.Is(err, io.EOF):
+		return problem.New(http.StatusBadRequest, problem.CodeInvalidBody, "Request body is empty")
+	case errors.As(err, &syntax), errors.Is(err, io.ErrUnexpectedEOF):
+		return problem.New(http.StatusBadRequest, problem.CodeInvalidBody, "Request body is not valid JSON")
+	case errors.As(err, &wrongType):
+		return Problem([]problem.FieldError{{
+			Field:   wrongType.Field,
+			Code:    CodeWrongType,
+			Message: fmt.Sprintf("%s must be a %s", wrongType.Field, jsonType(wrongType.Type)),
+		}})
+	}
+	return problem.New(http.StatusBadRequest, problem.CodeInvalidBody, "Invalid request body: "+err.Error())
+}
+
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	}
+	return "number"
+}
+
+// Check parses the validate tags of v, a struct or pointer to one, and
+// reports every malformed or unknown rule. The parsed rules are kept for
+// later requests.
+func Check(v any) error {
+	_, err := structRules(reflect.Indirect(reflect.ValueOf(v)).Type())
+	return err
+}
+
+// Must panics if Check fails for any of vs. Packages call it at init for
+// every request type they decode, so that a mistake in a tag stops the
+// server at startup rather than failing requests.
+func Must(vs ...any) {
+	for _, v := range vs {
+		if err := Check(v); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// Struct checks the validate tags of v, a struct or pointer to one, and
+// returns every violation. It fails only if a tag is malformed, which Must
+// catches at startup.
+func Struct(v any) ([]problem.FieldError, error) {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	fields, err := structRules(rv.Type())
+	if err != nil {
+		return nil, err
+	}
+	var errs []problem.FieldError
+	for _, f := range fields {
+		errs = append(errs, f.check(rv.Field(f.index))...)
+	}
+	return errs, nil
+}
+
+func jsonName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
+
+// rules is the parsed validate tag of one field.
+type rules struct {
+	name     string // JSON name
+	index    int
+	required bool
+	min, max int // -1 if unset
+	enum     []string
+	email    bool
+}
+
+// parsed holds the rules of every struct type checked so far, by
+// reflect.Type.
+var parsed sync.Map
+
+// structRules returns the rules of the exported fields of rt, parsing its
+// tags the first time.
+func structRules(rt reflect.Type) ([]rules, error) {
+	if fields, ok := parsed.Load(rt); ok {
+		return fields.([]rules), nil
+	}
+	if rt.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("validate: %s is not a struct", rt)
+	}
+	var (
+		fields []rules
+		errs   []error
+	)
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		name := jsonName(f)
+		if !f.IsExported() || name == "-" {
+			continue
+		}
+		r, err := parseRules(f, name)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("validate: %s.%s: %w", rt.Name(), f.Name, err))
+			continue
+		}
+		r.index = i
+		fields = append(fields, r)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	parsed.Store(rt, fields)
+	return fields, nil
+}
+
+func parseRules(f reflect.StructField, name string) (rules, error) {
+	r := rules{name: name, min: -1, max: -1}
+	ft := f.Type
+	if ft.Kind() == reflect.Pointer {
+		ft = ft.Elem()
+	}
+	for _, rule := range strings.Split(f.Tag.Get("validate"), ",") {
+		key, arg, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		if key != "" && key != "required" && ft.Kind() != reflect.String {
+			return r, fmt.Errorf("rule %q needs a string field", rule)
+		}
+		switch key {
+		case "":
+		case "required":
+			r.required = true
+		case "min", "max":
+			n, err := strconv.Atoi(arg)
+			if err != nil || n < 0 {
+				return r, fmt.Errorf("bad rule %q", rule)
+			}
+			if key == "min" {
+				r.min = n
+			} else {
+				r.max = n
+			}
+		case "enum":
+			if arg == "" {
+				return r, fmt.Errorf("bad rule %q", rule)
+			}
+			r.enum = strings.Split(arg, "|")
+		case "email":
+			r.email = true
+		default:
+			return r, fmt.Errorf("unknown rule %q", rule)
+		}
+	}
+	return r, nil
+}
+
+func (r *rules) check(v reflect.Value) []problem.FieldError {
+	fail := func(code, format string, args ...any) []problem.FieldError {
+		return []problem.FieldError{{Field: r.name, Code: code, Message: r.name + " " + fmt.Sprintf(format, args...)}}
+	}
+
+	// A nil pointer is an omitted field. A pointer to a blank value was sent
+	// explicitly, so the remaining rules still apply to it.
+	explicit := false
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			if r.required {
+				return fail(CodeRequired, "is required")
+			}
+			return nil
+		}
+		v, explicit = v.Elem(), true
+	}
+	if v.IsZero() || (v.Kind() == reflect.String && strings.TrimSpace(v.String()) == "") {
+		if r.required {
+			return fail(CodeRequired, "is required")
+		}
+		if !explicit {
+			return nil
+		}
+	}
+
+	if val, ok := v.Interface().(validator); ok && !val.IsValid() {
+		return fail(CodeInvalid, "has an invalid value %q", fmt.Sprint(v.Interface()))
+	}
+	if v.Kind() != reflect.String {
+		return nil
+	}
+	s := v.String()
+	length := utf8.RuneCountInString(strings.TrimSpace(s))
+	switch {
+	case r.min >= 0 && length < r.min:
+		return fail(CodeTooShort, "must be at least %d characters", r.min)
+	case r.max >= 0 && length > r.max:
+		return fail(CodeTooLong, "must be at most %d characters", r.max)
+	case r.enum != nil && !slices.Contains(r.enum, s):
+		return fail(CodeNotAllowed, "must be one of: %s", strings.Join(r.enum, ", "))
+	}
+	if r.email {
+		if addr, err := mail.ParseAddress(s); err != nil || addr.Address != s {
+			return fail(CodeInvalidEmail, "must be an email address")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+package validate
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"seattle-info-platform/internal/platform/problem"
+)
+
+type color string
+
+func (c color) IsValid() bool { return c == "red" || c == "blue" }
+
+type body struct {
+	Name     string  `json:"name" validate:"required,min=2,max=5"`
+	Nick     *string `json:"nick,omitempty" validate:"min=2"`
+	Kind     string  `json:"kind,omitempty" validate:"enum=a|b"`
+	Email    string  `json:"email,omitempty" validate:"email"`
+	Color    color   `json:"color,omitempty"`
+	Required *int    `json:"required" validate:"required"`
+	Skipped  string  `json:"-" validate:"required"`
+	NoTag    string
+}
+
+func ptr[T any](v T) *T { return &v }
+
+// valid returns a body that passes every rule.
+func valid() body {
+	return body{Name: "Ann", Required: ptr(1)}
+}
+
+func TestStruct(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		modify func(*body)
+		want   []problem.FieldError // only Field and Code are compared
+	}{
+		{"valid", func(*body) {}, nil},
+		{"optional fields set", func(b *body) {
+			b.Nick, b.Kind, b.Email, b.Color = ptr("Al"), "b", "ann@example.com", "red"
+		}, nil},
+
+		{"required missing", func(b *body) { b.Name = "" }, []problem.FieldError{{Field: "name", Code: CodeRequired}}},
+		{"required blank", func(b *body) { b.Name = "   " }, []problem.FieldError{{Field: "name", Code: CodeRequired}}},
+		{"required nil pointer", func(b *body) { b.Required = nil }, []problem.FieldError{{Field: "required", Code: CodeRequired}}},
+		{"required pointer to zero", func(b *body) { b.Required = ptr(0) }, []problem.FieldError{{Field: "required", Code: CodeRequired}}},
+
+		{"min", func(b *body) { b.Name = "A" }, []problem.FieldError{{Field: "name", Code: CodeTooShort}}},
+		{"min ignores surrounding space", func(b *body) { b.Name = " A  " }, []problem.FieldError{{Field: "name", Code: CodeTooShort}}},
+		{"min counts characters", func(b *body) { b.Name = "Žo" }, nil},
+		{"max", func(b *body) { b.Name = "Annabel" }, []problem.FieldError{{Field: "name", Code: CodeTooLong}}},
+		{"max counts characters", func(b *body) { b.Name = "Zoë Ö" }, nil},
+		{"omitted optional pointer", func(b *body) { b.Nick = nil }, nil},
+		{"explicit blank pointer", func(b *body) { b.Nick = ptr("") }, []problem.FieldError{{Field: "nick", Code: CodeTooShort}}},
+
+		{"enum", func(b *body) { b.Kind = "c" }, []problem.FieldError{{Field: "kind", Code: CodeNotAllowed}}},
+		{"enum is case sensitive", func(b *body) { b.Kind = "A" }, []problem.FieldError{{Field: "kind", Code: CodeNotAllowed}}},
+
+		{"email", func(b *body) { b.Email = "ann" }, []problem.FieldError{{Field: "email", Code: CodeInvalidEmail}}},
+		{"email with display name", func(b *body) { b.Email = "Ann <ann@example.com>" }, []problem.FieldError{{Field: "email", Code: CodeInvalidEmail}}},
+
+		{"IsValid", func(b *body) { b.Color = "green" }, []problem.FieldError{{Field: "color", Code: CodeInvalid}}},
+
+		{"every violation", func(b *body) {
+			b.Name, b.Kind, b.Email, b.Color, b.Required = "", "c", "x", "green", nil
+		}, []problem.FieldError{
+			{Field: "name", Code: CodeRequired},
+			{Field: "kind", Code: CodeNotAllowed},
+			{Field: "email", Code: CodeInvalidEmail},
+			{Field: "color", Code: CodeInvalid},
+			{Field: "required", Code: CodeRequired},
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			b := valid()
+			tc.modify(&b)
+			got, err := Struct(&b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := range got {
+				if !strings.HasPrefix(got[i].Message, got[i].Field+" ") {
+					t.Errorf("message %q does not start with the field name", got[i].Message)
+				}
+				got[i].Message = ""
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Struct = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+type badRules struct {
+	A string `json:"a" validate:"min=x"`
+	B string `json:"b" validate:"bogus"`
+	C int    `json:"c" validate:"max=3"`
+	D string `json:"d" validate:"enum="`
+	E string `json:"e" validate:"required,max=5"`
+}
+
+func TestBadRules(t *testing.T) {
+	err := Check(badRules{})
+	if err == nil {
+		t.Fatal("Check accepted bad rules")
+	}
+	for _, want := range []string{
+		`badRules.A: bad rule "min=x"`,
+		`badRules.B: unknown rule "bogus"`,
+		`badRules.C: rule "max=3" needs a string field`,
+		`badRules.D: bad rule "enum="`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Check error %q does not mention %q", err, want)
+		}
+	}
+	if strings.Contains(err.Error(), "badRules.E") {
+		t.Errorf("Check rejected a good field: %v", err)
+	}
+
+	// Requests fail instead of panicking.
+	if _, err := Struct(&badRules{}); err == nil {
+		t.Error("Struct accepted bad rules")
+	}
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"e":"x"}`))
+	if p := DecodeJSON(httptest.NewRecorder(), r, &badRules{}); p == nil || p.Status != http.StatusInternalServerError {
+		t.Errorf("DecodeJSON = %v, want a 500 problem", p)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Must did not panic")
+		}
+	}()
+	Must(body{}, badRules{})
+}
+
+func TestMust(t *testing.T) {
+	Must(body{}, &body{})
+	if err := Check(42); err == nil {
+		t.Error("Check accepted a non-struct")
+	}
+}
+
+func TestDecodeJSON(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+		code        string
+		fields      []string
+	}{
+		{"valid", "application/json", `{"name":"Ann","required":1}`, 0, "", nil},
+		{"no content type", "", `{"name":"Ann","required":1}`, 0, "", nil},
+		{"json suffix", "application/merge-patch+json; charset=utf-8", `{"name":"Ann","required":1}`, 0, "", nil},
+		{"wrong content type", "text/plain", `{"name":"Ann","required":1}`, http.StatusUnsupportedMediaType, CodeUnsupportedMediaType, nil},
+		{"empty", "application/json", ``, http.StatusBadRequest, problem.CodeInvalidBody, nil},
+		{"syntax error", "application/json", `{"name":`, http.StatusBadRequest, problem.CodeInvalidBody, nil},
+		{"two objects", "application/json", `{"name":"Ann","required":1}{}`, http.StatusBadRequest, problem.CodeInvalidBody, nil},
+		{"too large", "application/json", `{"name":"` + strings.Repeat("a", MaxBodyBytes) + `"}`, http.StatusRequestEntityTooLarge, CodeBodyTooLarge, nil},
+		{"wrong type", "application/json", `{"name":5,"required":1}`, http.StatusBadRequest, problem.CodeValidationFailed, []string{"name"}},
+		{"unknown fields with violations", "application/json", `{"name":"A","zeta":1,"alpha":2,"NoTag":"x"}`, http.StatusBadRequest, problem.CodeValidationFailed,
+			[]string{"alpha", "zeta", "name", "required"}},
+		{"field names ignore case", "application/json", `{"NAME":"Ann","Required":1}`, 0, "", nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tc.body))
+			if tc.contentType != "" {
+				r.Header.Set("Content-Type", tc.contentType)
+			}
+			var dst body
+			p := DecodeJSON(httptest.NewRecorder(), r, &dst)
+			if tc.status == 0 {
+				if p != nil {
+					t.Fatalf("DecodeJSON: %v", p)
+				}
+				if dst.Name != "Ann" || dst.Required == nil || *dst.Required != 1 {
+					t.Errorf("decoded %+v", dst)
+				}
+				return
+			}
+			if p == nil {
+				t.Fatal("DecodeJSON succeeded, want a problem")
+			}
+			if p.Status != tc.status || p.Code != tc.code {
+				t.Errorf("problem = %d %s, want %d %s", p.Status, p.Code, tc.status, tc.code)
+			}
+			var fields []string
+			for _, e := range p.Errors {
+				fields = append(fields, e.Field)
+			}
+			if !reflect.DeepEqual(fields, tc.fields) {
+				t.Errorf("fields = %q, want %q", fields, tc.fields)
+			}
+		})
+	}
+}
+
+func TestDecodeOptionalJSON(t *testing.T) {
+	type optional struct {
+		Reason string `json:"reason" validate:"max=5"`
+	}
+	for _, tc := range []struct {
+		name    string
+		body    io.Reader
+		reason  string
+		problem string
+	}{
+		{"empty", strings.NewReader(""), "", ""},
+		{"blank", strings.NewReader(" \n"), "", ""},
+		// A reader of unknown length is sent chunked, without a Content-Length.
+		{"empty chunked", io.MultiReader(), "", ""},
+		{"set", strings.NewReader(`{"reason":"spam"}`), "spam", ""},
+		{"invalid", strings.NewReader(`{"reason":"too long"}`), "", problem.CodeValidationFailed},
+		{"syntax error", strings.NewReader(`{`), "", problem.CodeInvalidBody},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", tc.body)
+			var dst optional
+			p := DecodeOptionalJSON(httptest.NewRecorder(), r, &dst)
+			if tc.problem != "" {
+				if p == nil || p.Code != tc.problem {
+					t.Fatalf("DecodeOptionalJSON = %v, want %s", p, tc.problem)
+				}
+				return
+			}
+			if p != nil {
+				t.Fatalf("DecodeOptionalJSON: %v", p)
+			}
+			if dst.Reason != tc.reason {
+				t.Errorf("reason = %q, want %q", dst.Reason, tc.reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package user
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Transitions lists, for each status, the statuses a user may move to.
+// Any status except Inactive may move to Inactive; Inactive is final.
+var Transitions = map[UserStatus][]UserStatus{
+	StatusPendingApproval: {StatusActive, StatusRejected, StatusInactive},
+	StatusActive:          {StatusSuspended, StatusInactive},
+	StatusSuspended:       {StatusActive, StatusInactive},
+	StatusRejected:        {StatusInactive},
+	StatusInactive:        {},
+}
+
+// reasonRequired marks the statuses that must be entered with a reason.
+var reasonRequired = map[UserStatus]bool{
+	StatusRejected:  true,
+	StatusSuspended: true,
+}
+
+// ErrReasonRequired is returned when moving to a status that needs a reason
+// without giving one.
+var ErrReasonRequired = errors.New("user: a reason is required for this status change")
+
+// TransitionError reports a status change that Transitions, or the action
+// asking for it, does not allow.
+type TransitionError struct {
+	From, To UserStatus
+	// Required is the status the action starts from, if it needs one:
+	// approval needs PendingApproval even though Suspended users may also
+	// become Active.
+	Required UserStatus
+}
+
+func (e *TransitionError) Error() string {
+	if e.Required != "" && e.From != e.Required {
+		return fmt.Sprintf("user: cannot change status to %q from %q, only from %q", e.To, e.From, e.Required)
+	}
+	return fmt.Sprintf("user: cannot change status from %q to %q", e.From, e.To)
+}
+
+// Allowed returns the statuses reachable from e.From.
+func (e *TransitionError) Allowed() []UserStatus {
+	return Transitions[e.From]
+}
+
+// CanTransition reports whether a user in status from may move to status to.
+func CanTransition(from, to UserStatus) bool {
+	for _, s := range Transitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Transition moves u to status to, recording reason. The reason is kept for
+// statuses that explain a restriction and cleared when the user becomes
+// active again.
+func (u *User) Transition(to UserStatus, reason string) error {
+	if !CanTransition(u.Status, to) {
+		return &TransitionError{From: u.Status, To: to}
+	}
+	reason = strings.TrimSpace(reason)
+	if reasonRequired[to] && reason == "" {
+		return ErrReasonRequired
+	}
+	u.Status = to
+	if to == StatusActive {
+		reason = ""
+	}
+	u.StatusReason = reason
+	return nil
+}
@@ -0,0 +1,86 @@
+package user
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+var statuses = []UserStatus{StatusPendingApproval, StatusActive, StatusSuspended, StatusInactive, StatusRejected}
+
+func TestCanTransition(t *testing.T) {
+	allowed := map[[2]UserStatus]bool{
+		{StatusPendingApproval, StatusActive}:   true,
+		{StatusPendingApproval, StatusRejected}: true,
+		{StatusPendingApproval, StatusInactive}: true,
+		{StatusActive, StatusSuspended}:         true,
+		{StatusActive, StatusInactive}:          true,
+		{StatusSuspended, StatusActive}:         true,
+		{StatusSuspended, StatusInactive}:       true,
+		{StatusRejected, StatusInactive}:        true,
+	}
+	for _, from := range statuses {
+		for _, to := range statuses {
+			if got, want := CanTransition(from, to), allowed[[2]UserStatus{from, to}]; got != want {
+				t.Errorf("CanTransition(%q, %q) = %v, want %v", from, to, got, want)
+			}
+		}
+	}
+	if CanTransition("Bogus", StatusActive) || CanTransition(StatusActive, "Bogus") {
+		t.Error("CanTransition allows an unknown status")
+	}
+}
+
+func TestTransition(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		from       UserStatus
+		fromReason string
+		to         UserStatus
+		reason     string
+		err        error
+		wantReason string
+	}{
+		{"approve", StatusPendingApproval, "", StatusActive, "", nil, ""},
+		{"reject", StatusPendingApproval, "", StatusRejected, " spam ", nil, "spam"},
+		{"reject without reason", StatusPendingApproval, "", StatusRejected, "  ", ErrReasonRequired, ""},
+		{"suspend", StatusActive, "", StatusSuspended, "abuse", nil, "abuse"},
+		{"suspend without reason", StatusActive, "", StatusSuspended, "", ErrReasonRequired, ""},
+		{"reactivate clears reason", StatusSuspended, "abuse", StatusActive, "appeal granted", nil, ""},
+		{"deactivate without reason", StatusActive, "", StatusInactive, "", nil, ""},
+		{"deactivate with reason", StatusSuspended, "abuse", StatusInactive, "left", nil, "left"},
+		{"inactive is final", StatusInactive, "left", StatusActive, "", &TransitionError{From: StatusInactive, To: StatusActive}, "left"},
+		{"rejected cannot activate", StatusRejected, "spam", StatusActive, "", &TransitionError{From: StatusRejected, To: StatusActive}, "spam"},
+		{"same status", StatusActive, "", StatusActive, "", &TransitionError{From: StatusActive, To: StatusActive}, ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			u := &User{Status: tc.from, StatusReason: tc.fromReason}
+			err := u.Transition(tc.to, tc.reason)
+			if !reflect.DeepEqual(err, tc.err) {
+				t.Fatalf("Transition = %v, want %v", err, tc.err)
+			}
+			wantStatus := tc.to
+			if err != nil {
+				wantStatus = tc.from
+			}
+			if u.Status != wantStatus || u.StatusReason != tc.wantReason {
+				t.Errorf("user = %q (%q), want %q (%q)", u.Status, u.StatusReason, wantStatus, tc.wantReason)
+			}
+		})
+	}
+}
+
+func TestTransitionError(t *testing.T) {
+	u := &User{Status: StatusSuspended}
+	err := u.Transition(StatusRejected, "late")
+	var te *TransitionError
+	if !errors.As(err, &te) {
+		t.Fatalf("Transition = %v, want a *TransitionError", err)
+	}
+	if want := []UserStatus{StatusActive, StatusInactive}; !reflect.DeepEqual(te.Allowed(), want) {
+		t.Errorf("Allowed = %q, want %q", te.Allowed(), want)
+	}
+	if want := `user: cannot change status from "Suspended" to "Rejected"`; err.Error() != want {
+		t.Errorf("Error = %q, want %q", err, want)
+	}
+}
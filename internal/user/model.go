@@ -8,22 +8,40 @@ import (
 type UserRole string
 
 const (
-	RoleUser  UserRole = "user"
-	RoleAdmin UserRole = "admin"
+	RoleUser      UserRole = "user"
+	RoleModerator UserRole = "moderator" // Reviews users and listings but cannot manage roles or categories
+	RoleAdmin     UserRole = "admin"
 )
 
+// IsValid reports whether r is one of the known roles.
+func (r UserRole) IsValid() bool {
+	switch r {
+	case RoleUser, RoleModerator, RoleAdmin:
+		return true
+	}
+	return false
+}
+
 // UserStatus defines the possible statuses of a user account
 type UserStatus string
 
 const (
 	StatusPendingApproval UserStatus = "Pending Approval"
 	StatusActive          UserStatus = "Active"
-	// Conceptual statuses for future use (not directly managed in MVP for approval workflow)
-	// StatusSuspended UserStatus = "Suspended"
-	// StatusInactive UserStatus = "Inactive"
-	// StatusRejected UserStatus = "Rejected" // Or simply delete
+	StatusSuspended       UserStatus = "Suspended" // Temporarily blocked; can be reactivated
+	StatusInactive        UserStatus = "Inactive"  // Deactivated for good
+	StatusRejected        UserStatus = "Rejected"  // Registration was not approved
 )
 
+// IsValid reports whether s is one of the known statuses.
+func (s UserStatus) IsValid() bool {
+	switch s {
+	case StatusPendingApproval, StatusActive, StatusSuspended, StatusInactive, StatusRejected:
+		return true
+	}
+	return false
+}
+
 // User represents a user in the system.
 type User struct {
 	ID                string     `json:"id"` // UUID
@@ -32,12 +50,13 @@ type User struct {
 	LastName          string     `json:"last_name,omitempty"`
 	Role              UserRole   `json:"role"`
 	Status            UserStatus `json:"status"`
+	StatusReason      string     `json:"status_reason,omitempty"` // Why the user was rejected, suspended or deactivated
 	RegistrationDate  time.Time  `json:"registration_date"`
 	LastLoginDate     time.Time  `json:"last_login_date,omitempty"`
 	ProfilePictureURL string     `json:"profile_picture_url,omitempty"`
 	AuthProvider      string     `json:"auth_provider,omitempty"` // e.g., "firebase"
 	IsEmailVerified   bool       `json:"is_email_verified,omitempty"`
 	// IsFirstPostApproved bool       `json:"is_first_post_approved,omitempty"` // specific to app logic, maybe not for generic user model
-	CreatedAt         time.Time  `json:"created_at"`
-	UpdatedAt         time.Time  `json:"updated_at"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -1,17 +1,145 @@
 package user
 
-// This file can be used for user service logic in the future.
-// For now, it just ensures the package is valid.
+import (
+	"context"
+	"errors"
+	"time"
+
+	"seattle-info-platform/internal/audit"
+)
+
+var (
+	// ErrInvalidRole is returned when assigning a role that IsValid rejects.
+	ErrInvalidRole = errors.New("user: invalid role")
+	// ErrSelfChange is returned when the caller targets their own account:
+	// nobody may change their own status or role.
+	ErrSelfChange = errors.New("user: cannot change your own account")
+	// ErrOutranked is returned when a caller who is not an admin acts on a
+	// moderator or admin account. Moderators manage users only.
+	ErrOutranked = errors.New("user: only admins can change moderator and admin accounts")
+	// ErrLastAdmin is returned by the store for a change that would leave no
+	// active admin, locking everyone out of the admin API.
+	ErrLastAdmin = errors.New("user: cannot remove the last active admin")
+)
+
+// Store is the persistence the Service needs. database.UserRepository
+// satisfies it.
+type Store interface {
+	// UpdateFunc atomically loads the user, applies fn and stores the result.
+	UpdateFunc(ctx context.Context, id string, fn func(*User) error) (*User, error)
+}
+
+// Service applies account lifecycle changes, enforcing Transitions and who
+// may change whom, and records each one in the audit log. A change whose
+// record fails is not stored.
+type Service struct {
+	store Store
+	audit audit.Recorder
+	now   func() time.Time
+}
+
+// NewService returns a service that persists changes in store and records
+// them with rec.
+func NewService(store Store, rec audit.Recorder) *Service {
+	return &Service{store: store, audit: rec, now: time.Now}
+}
+
+// Approve activates a pending registration. It refuses users in any other
+// status, even those Transitions lets become active.
+func (s *Service) Approve(ctx context.Context, by *User, id string) (*User, error) {
+	return s.transition(ctx, by, "user.approve", id, StatusPendingApproval, StatusActive, "")
+}
+
+// Reject declines a pending registration. A reason is required.
+func (s *Service) Reject(ctx context.Context, by *User, id, reason string) (*User, error) {
+	return s.transition(ctx, by, "user.reject", id, "", StatusRejected, reason)
+}
+
+// Suspend blocks an active user until reactivated. A reason is required.
+func (s *Service) Suspend(ctx context.Context, by *User, id, reason string) (*User, error) {
+	return s.transition(ctx, by, "user.suspend", id, "", StatusSuspended, reason)
+}
+
+// Reactivate lifts a suspension. It refuses users that are not suspended,
+// so a pending registration cannot skip review.
+func (s *Service) Reactivate(ctx context.Context, by *User, id string) (*User, error) {
+	return s.transition(ctx, by, "user.reactivate", id, StatusSuspended, StatusActive, "")
+}
+
+// Deactivate closes an account permanently. The reason is optional.
+func (s *Service) Deactivate(ctx context.Context, by *User, id, reason string) (*User, error) {
+	return s.transition(ctx, by, "user.deactivate", id, "", StatusInactive, reason)
+}
+
+// ChangeRole assigns a new role.
+func (s *Service) ChangeRole(ctx context.Context, by *User, id string, role UserRole) (*User, error) {
+	if !role.IsValid() {
+		return nil, ErrInvalidRole
+	}
+	return s.update(ctx, by, "user.change_role", id, "", func(u *User) error {
+		u.Role = role
+		u.UpdatedAt = s.now()
+		return nil
+	})
+}
+
+// transition moves the user to status to. A non-empty from is the only
+// status the action may start from; the check runs inside the store write,
+// against the stored user.
+func (s *Service) transition(ctx context.Context, by *User, action, id string, from, to UserStatus, reason string) (*User, error) {
+	return s.update(ctx, by, action, id, reason, func(u *User) error {
+		if from != "" && u.Status != from {
+			return &TransitionError{From: u.Status, To: to, Required: from}
+		}
+		if err := u.Transition(to, reason); err != nil {
+			return err
+		}
+		u.UpdatedAt = s.now()
+		return nil
+	})
+}
+
+// update checks that by may change the user, then applies fn and records
+// the change in the same atomic write, so a change that cannot be recorded
+// is not stored either. The store refuses, with ErrLastAdmin, changes that
+// leave no active admin; as with a failed save, that happens after the
+// record.
+func (s *Service) update(ctx context.Context, by *User, action, id, reason string, fn func(*User) error) (*User, error) {
+	return s.store.UpdateFunc(ctx, id, func(u *User) error {
+		if err := mayChange(by, u); err != nil {
+			return err
+		}
+		before := *u
+		if err := fn(u); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, audit.Event{
+			Action: action, TargetType: audit.TargetUser, TargetID: id, Reason: reason,
+			Before: &before, After: u,
+		})
+	})
+}
+
+// mayChange reports whether by may change target's status or role.
+func mayChange(by, target *User) error {
+	switch {
+	case by.ID == target.ID:
+		return ErrSelfChange
+	case by.Role != RoleAdmin && target.Role != RoleUser:
+		return ErrOutranked
+	}
+	return nil
+}
 
 func GetMockUser(id string) *User {
 	// This is a mock function. In a real application, you would fetch this from a database.
 	return &User{
-		ID: id,
-		Email: "user_" + id + "@example.com",
+		ID:        id,
+		Email:     "user_" + id + "@example.com",
 		FirstName: "Mock",
-		LastName: "User",
-		Role: RoleUser,
-		Status: StatusActive,
+		LastName:  "User",
+		Role:      RoleUser,
+		Status:    StatusActive,
 		// Populate other fields as necessary
 	}
 }
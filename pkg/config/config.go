@@ -1,3 +1,256 @@
+// Package config defines the server configuration and loads it from, in
+// increasing order of precedence: built-in defaults, a TOML config file,
+// SEATTLE_* environment variables and command-line flags.
+//
+// Every setting is declared once as a struct field. Its `config` tag names
+// the key inside its section (so Server.Addr is `server.addr` in the file,
+// SEATTLE_SERVER_ADDR in the environment and -server.addr on the command
+// line), `help` documents it and `secret:"true"` keeps it out of printed
+// output.
 package config
 
-// TODO: Implement config related logic
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// GoogleCertsURL publishes the x509 certificates that sign Firebase ID tokens.
+const GoogleCertsURL = "https://www.googleapis.com/robot/v1/metadata/x509/securetoken@system.gserviceaccount.com"
+
+// Config is the complete server configuration.
+type Config struct {
+	Server    ServerConfig    `config:"server"`
+	Database  DatabaseConfig  `config:"database"`
+	Auth      AuthConfig      `config:"auth"`
+	Audit     AuditConfig     `config:"audit"`
+	Log       LogConfig       `config:"log"`
+	RateLimit RateLimitConfig `config:"rate_limit"`
+	CORS      CORSConfig      `config:"cors"`
+	Security  SecurityConfig  `config:"security"`
+
+	sources map[string]string // setting key -> where its value came from
+}
+
+// ServerConfig controls the HTTP listener.
+type ServerConfig struct {
+	Addr         string        `config:"addr" help:"address to listen on"`
+	ReadTimeout  time.Duration `config:"read_timeout" help:"maximum duration for reading a request"`
+	WriteTimeout time.Duration `config:"write_timeout" help:"maximum duration for writing a response"`
+	IdleTimeout  time.Duration `config:"idle_timeout" help:"keep-alive connection idle timeout"`
+	// ShutdownTimeout bounds how long in-flight requests may drain after a
+	// termination signal before the rest of the server is stopped anyway.
+	ShutdownTimeout time.Duration `config:"shutdown_timeout" help:"maximum duration for graceful shutdown"`
+	// ShutdownDelay keeps serving, with readiness failing, after a
+	// termination signal so load balancers stop routing here before the
+	// listener closes. It counts towards ShutdownTimeout.
+	ShutdownDelay time.Duration `config:"shutdown_delay" help:"how long to keep serving with readiness failing before draining"`
+	StaticDir     string        `config:"static_dir" help:"directory served under /admin/"`
+	// TrustedProxies lists the reverse proxies whose X-Forwarded-For entries
+	// are believed when finding a client's address for rate limits and the
+	// audit log.
+	TrustedProxies []string `config:"trusted_proxies" help:"addresses or CIDR ranges of trusted reverse proxies"`
+	// MetricsAddr is a separate listener for the Prometheus metrics, which
+	// are not served on Addr. Keep it off the public network: it has no
+	// authentication or rate limits.
+	MetricsAddr string `config:"metrics_addr" help:"address to serve /metrics on; empty disables metrics"`
+}
+
+// DatabaseConfig locates the file-backed store.
+type DatabaseConfig struct {
+	Path        string `config:"path" help:"path to the database file"`
+	AutoMigrate bool   `config:"auto_migrate" help:"apply pending schema migrations before starting"`
+	// SeedDemo fills an empty database with the demo users, categories and
+	// listings the admin dashboard was built against. Never enable it in
+	// production: the demo admin account is well known.
+	SeedDemo bool `config:"seed_demo" help:"fill an empty database with demo records"`
+}
+
+// AuthConfig configures Firebase ID token verification.
+type AuthConfig struct {
+	FirebaseProjectID string `config:"firebase_project_id" help:"Firebase project ID that ID tokens must be issued for"`
+	CertsURL          string `config:"certs_url" help:"URL of the token signing certificates (x509 or JWKS JSON)"`
+	CertsFile         string `config:"certs_file" help:"read token signing certificates from this file instead of certs_url"`
+	// BootstrapAdminUID gives a fresh database its first administrator:
+	// at startup, if no account has this Firebase UID, an active admin
+	// account is created for it. An existing account is left as it is.
+	BootstrapAdminUID string `config:"bootstrap_admin_uid" help:"Firebase UID that gets an active admin account at startup if it has no account"`
+}
+
+// AuditConfig locates the audit log and the key of its hash chain.
+type AuditConfig struct {
+	Path    string `config:"path" help:"path to the append-only audit log"`
+	HMACKey string `config:"hmac_key" help:"key of the audit log hash chain; without it only accidental edits are detectable" secret:"true"`
+}
+
+// LogConfig controls the server's structured logs.
+type LogConfig struct {
+	Level  string `config:"level" help:"minimum level logged: debug, info, warn or error"`
+	Format string `config:"format" help:"log output format: text or json"`
+}
+
+// RateLimitConfig throttles each client per route group: public routes by
+// client address, admin routes by address before authentication and by
+// account after it. A rate of zero disables a group's limit; probes are
+// never limited.
+type RateLimitConfig struct {
+	PublicRate   float64 `config:"public_rate" help:"requests per second each client address may make to public API routes"`
+	PublicBurst  int     `config:"public_burst" help:"requests a client address may make at once to public API routes"`
+	AdminIPRate  float64 `config:"admin_ip_rate" help:"requests per second each client address may make to admin API routes, counted before authentication"`
+	AdminIPBurst int     `config:"admin_ip_burst" help:"requests a client address may make at once to admin API routes"`
+	AdminRate    float64 `config:"admin_rate" help:"requests per second each account may make to admin API routes"`
+	AdminBurst   int     `config:"admin_burst" help:"requests an account may make at once to admin API routes"`
+	MaxClients   int     `config:"max_clients" help:"clients tracked per route group; the least recently seen are forgotten beyond this"`
+}
+
+// CORSConfig lets browser pages on other origins, such as the admin
+// dashboard's development server, call the API.
+type CORSConfig struct {
+	AllowedOrigins   []string      `config:"allowed_origins" help:"origins allowed to call the API from a browser, such as http://localhost:3000, or * for any; empty disables CORS"`
+	AllowedMethods   []string      `config:"allowed_methods" help:"methods allowed in cross-origin requests"`
+	AllowedHeaders   []string      `config:"allowed_headers" help:"request headers allowed in cross-origin requests"`
+	AllowCredentials bool          `config:"allow_credentials" help:"let cross-origin requests send cookies and HTTP authentication"`
+	MaxAge           time.Duration `config:"max_age" help:"how long browsers may cache preflight responses"`
+}
+
+// SecurityConfig sets the browser security headers.
+type SecurityConfig struct {
+	HSTSMaxAge time.Duration `config:"hsts_max_age" help:"max-age of Strict-Transport-Security; 0 omits the header"`
+	AdminCSP   string        `config:"admin_csp" help:"Content-Security-Policy of the /admin/ static site"`
+}
+
+// Default returns the configuration used when nothing else is specified.
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Addr:            ":8080",
+			ReadTimeout:     5 * time.Second,
+			WriteTimeout:    10 * time.Second,
+			IdleTimeout:     120 * time.Second,
+			ShutdownTimeout: 30 * time.Second,
+			StaticDir:       "./web/admin",
+			MetricsAddr:     "127.0.0.1:9090",
+		},
+		Database: DatabaseConfig{
+			Path: "./data/seattle-info.json",
+		},
+		Auth: AuthConfig{
+			FirebaseProjectID: "seattle-info",
+			CertsURL:          GoogleCertsURL,
+		},
+		Audit: AuditConfig{
+			Path: "./data/audit.jsonl",
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "text",
+		},
+		RateLimit: RateLimitConfig{
+			PublicRate:   5,
+			PublicBurst:  20,
+			AdminIPRate:  20,
+			AdminIPBurst: 80,
+			AdminRate:    10,
+			AdminBurst:   40,
+			MaxClients:   10000,
+		},
+		CORS: CORSConfig{
+			AllowedMethods: []string{"GET", "POST", "PUT", "DELETE"},
+			AllowedHeaders: []string{"Authorization", "Content-Type", "X-Request-ID"},
+			MaxAge:         10 * time.Minute,
+		},
+		Security: SecurityConfig{
+			HSTSMaxAge: 365 * 24 * time.Hour,
+			// The pages use inline scripts and styles, and sign in with
+			// Firebase Authentication.
+			AdminCSP: "default-src 'self'; script-src 'self' 'unsafe-inline'; style-src 'self' 'unsafe-inline'; " +
+				"img-src 'self' data:; connect-src 'self' https://identitytoolkit.googleapis.com https://securetoken.googleapis.com; " +
+				"frame-src https://*.firebaseapp.com; object-src 'none'; base-uri 'self'; form-action 'self'; frame-ancestors 'none'",
+		},
+	}
+}
+
+// Validate reports every invalid setting at once.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, key, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: "+format, append([]any{key}, args...)...))
+		}
+	}
+
+	if _, _, err := net.SplitHostPort(c.Server.Addr); err != nil {
+		errs = append(errs, fmt.Errorf("server.addr: %q is not a host:port address", c.Server.Addr))
+	}
+	if c.Server.MetricsAddr != "" {
+		if _, _, err := net.SplitHostPort(c.Server.MetricsAddr); err != nil {
+			errs = append(errs, fmt.Errorf("server.metrics_addr: %q is not a host:port address", c.Server.MetricsAddr))
+		}
+		check(c.Server.MetricsAddr != c.Server.Addr, "server.metrics_addr", "must differ from server.addr")
+	}
+	check(c.Server.ReadTimeout > 0, "server.read_timeout", "must be positive")
+	check(c.Server.WriteTimeout > 0, "server.write_timeout", "must be positive")
+	check(c.Server.IdleTimeout > 0, "server.idle_timeout", "must be positive")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout", "must be positive")
+	check(c.Server.ShutdownDelay >= 0 && c.Server.ShutdownDelay < c.Server.ShutdownTimeout, "server.shutdown_delay", "must be at least zero and below server.shutdown_timeout")
+	check(c.Server.StaticDir != "", "server.static_dir", "must not be empty")
+	for _, proxy := range c.Server.TrustedProxies {
+		check(validProxy(proxy), "server.trusted_proxies", "%q is not an address or CIDR range", proxy)
+	}
+
+	check(c.Database.Path != "", "database.path", "must not be empty")
+
+	check(c.Auth.FirebaseProjectID != "", "auth.firebase_project_id", "must not be empty")
+	check(c.Auth.CertsURL != "" || c.Auth.CertsFile != "", "auth.certs_url", "must be set unless auth.certs_file is")
+
+	check(c.Audit.Path != "", "audit.path", "must not be empty")
+	check(c.Audit.HMACKey == "" || len(c.Audit.HMACKey) >= 32, "audit.hmac_key", "must be at least 32 characters")
+
+	var level slog.Level
+	check(level.UnmarshalText([]byte(c.Log.Level)) == nil, "log.level", "%q is not debug, info, warn or error", c.Log.Level)
+	check(c.Log.Format == "text" || c.Log.Format == "json", "log.format", "%q is not text or json", c.Log.Format)
+
+	check(c.RateLimit.PublicRate >= 0, "rate_limit.public_rate", "must not be negative")
+	check(c.RateLimit.PublicRate == 0 || c.RateLimit.PublicBurst >= 1, "rate_limit.public_burst", "must be at least 1")
+	check(c.RateLimit.AdminIPRate >= 0, "rate_limit.admin_ip_rate", "must not be negative")
+	check(c.RateLimit.AdminIPRate == 0 || c.RateLimit.AdminIPBurst >= 1, "rate_limit.admin_ip_burst", "must be at least 1")
+	check(c.RateLimit.AdminRate >= 0, "rate_limit.admin_rate", "must not be negative")
+	check(c.RateLimit.AdminRate == 0 || c.RateLimit.AdminBurst >= 1, "rate_limit.admin_burst", "must be at least 1")
+	check(c.RateLimit.MaxClients >= 1, "rate_limit.max_clients", "must be at least 1")
+
+	for _, origin := range c.CORS.AllowedOrigins {
+		check(origin == "*" || validOrigin(origin), "cors.allowed_origins", "%q is not * or an origin such as https://example.com", origin)
+	}
+	check(!c.CORS.AllowCredentials || !slices.Contains(c.CORS.AllowedOrigins, "*"), "cors.allow_credentials", "cannot be combined with allowed origin *")
+	for _, method := range c.CORS.AllowedMethods {
+		check(method != "" && method == strings.ToUpper(method), "cors.allowed_methods", "%q is not an upper case method name", method)
+	}
+	check(c.CORS.MaxAge >= 0, "cors.max_age", "must not be negative")
+
+	check(c.Security.HSTSMaxAge >= 0, "security.hsts_max_age", "must not be negative")
+
+	return errors.Join(errs...)
+}
+
+// validProxy reports whether s is an IP address or a CIDR range.
+func validProxy(s string) bool {
+	if _, err := netip.ParsePrefix(s); err == nil {
+		return true
+	}
+	_, err := netip.ParseAddr(s)
+	return err == nil
+}
+
+// validOrigin reports whether s is a serialized origin: a scheme and host,
+// with an optional port but no path.
+func validOrigin(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" &&
+		u.Path == "" && u.User == nil && u.RawQuery == "" && u.Fragment == ""
+}
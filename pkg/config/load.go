@@ -0,0 +1,251 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	// EnvPrefix starts the environment variable of every setting.
+	EnvPrefix = "SEATTLE_"
+	// FileEnv names the config file when the -config flag is not given.
+	FileEnv = EnvPrefix + "CONFIG"
+)
+
+// setting is one leaf field of Config.
+type setting struct {
+	key    string // section.key
+	env    string
+	help   string
+	secret bool
+	value  reflect.Value
+}
+
+// settings lists the leaf fields of c in declaration order.
+func settings(c *Config) []setting {
+	var out []setting
+	root := reflect.ValueOf(c).Elem()
+	for i := 0; i < root.NumField(); i++ {
+		sectionField := root.Type().Field(i)
+		section := sectionField.Tag.Get("config")
+		if section == "" {
+			continue
+		}
+		sv := root.Field(i)
+		for j := 0; j < sv.NumField(); j++ {
+			f := sv.Type().Field(j)
+			name := f.Tag.Get("config")
+			if name == "" {
+				continue
+			}
+			key := section + "." + name
+			out = append(out, setting{
+				key:    key,
+				env:    EnvPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_")),
+				help:   f.Tag.Get("help"),
+				secret: f.Tag.Get("secret") == "true",
+				value:  sv.Field(j),
+			})
+		}
+	}
+	return out
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// set converts v (a string, or []string from a config file array) to the
+// setting's type and stores it.
+func (s setting) set(v any) error {
+	if list, ok := v.([]string); ok {
+		if s.value.Kind() != reflect.Slice {
+			return fmt.Errorf("expected a single value, got a list")
+		}
+		s.value.Set(reflect.ValueOf(append([]string(nil), list...)))
+		return nil
+	}
+	str := v.(string)
+
+	switch {
+	case s.value.Type() == durationType:
+		d, err := time.ParseDuration(str)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", str)
+		}
+		s.value.SetInt(int64(d))
+	case s.value.Kind() == reflect.String:
+		s.value.SetString(str)
+	case s.value.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(str)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", str)
+		}
+		s.value.SetBool(b)
+	case s.value.Kind() == reflect.Int:
+		n, err := strconv.Atoi(str)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", str)
+		}
+		s.value.SetInt(int64(n))
+	case s.value.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(str, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", str)
+		}
+		s.value.SetFloat(f)
+	case s.value.Kind() == reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(str, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		s.value.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported setting type %s", s.value.Type())
+	}
+	return nil
+}
+
+// format renders the setting's value as a TOML value.
+func (s setting) format() string {
+	v := s.value
+	switch {
+	case s.secret:
+		if v.IsZero() {
+			return `""`
+		}
+		return `"[REDACTED]"`
+	case v.Type() == durationType:
+		return strconv.Quote(time.Duration(v.Int()).String())
+	case v.Kind() == reflect.String:
+		return strconv.Quote(v.String())
+	case v.Kind() == reflect.Slice:
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = strconv.Quote(v.Index(i).String())
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// Load returns the effective configuration. It registers a flag for every
+// setting plus -config on fs, parses args with it, then applies defaults, the
+// config file (-config or $SEATTLE_CONFIG), environment variables from
+// lookupEnv and finally the flags. All conversion and validation problems are
+// reported together. Positional arguments remain available from fs.Args.
+func Load(fs *flag.FlagSet, args []string, lookupEnv func(string) (string, bool)) (*Config, error) {
+	cfg := Default()
+	all := settings(cfg)
+	byKey := make(map[string]setting, len(all))
+	for _, s := range all {
+		byKey[s.key] = s
+	}
+
+	configPath := fs.String("config", "", "path to a TOML config file (env "+FileEnv+")")
+	flagValues := make(map[string]string)
+	for _, s := range all {
+		key := s.key
+		usage := fmt.Sprintf("%s (env %s)", s.help, s.env)
+		record := func(v string) error {
+			flagValues[key] = v
+			return nil
+		}
+		if s.value.Kind() == reflect.Bool {
+			fs.BoolFunc(key, usage, record) // allows a bare -key
+		} else {
+			fs.Func(key, usage, record)
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	sources := make(map[string]string, len(all))
+	apply := func(s setting, v any, source string) {
+		if err := s.set(v); err != nil {
+			errs = append(errs, fmt.Errorf("%s (from %s): %w", s.key, source, err))
+			return
+		}
+		sources[s.key] = source
+	}
+
+	path := *configPath
+	if path == "" {
+		path, _ = lookupEnv(FileEnv)
+	}
+	if path != "" {
+		values, err := parseTOMLFile(path)
+		if err != nil {
+			return nil, err
+		}
+		keys := make([]string, 0, len(values))
+		for key := range values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			v := values[key]
+			s, ok := byKey[key]
+			if !ok {
+				errs = append(errs, fmt.Errorf("%s (from %s): unknown setting", key, path))
+				continue
+			}
+			apply(s, v, path)
+		}
+	}
+	for _, s := range all {
+		if v, ok := lookupEnv(s.env); ok {
+			apply(s, v, "$"+s.env)
+		}
+	}
+	for _, s := range all {
+		if v, ok := flagValues[s.key]; ok {
+			apply(s, v, "-"+s.key)
+		}
+	}
+
+	// Settings that failed to convert keep their previous value, so validating
+	// anyway still reports every other problem in the same run.
+	if err := cfg.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	cfg.sources = sources
+	return cfg, nil
+}
+
+// Print writes c as a TOML config file with secrets redacted. Each setting
+// that did not come from the defaults is annotated with its source.
+func (c *Config) Print(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 1, ' ', 0)
+	section := ""
+	for _, s := range settings(c) {
+		sec, name, _ := strings.Cut(s.key, ".")
+		if sec != section {
+			if section != "" {
+				fmt.Fprintln(tw)
+			}
+			fmt.Fprintf(tw, "[%s]\n", sec)
+			section = sec
+		}
+		if src, ok := c.sources[s.key]; ok {
+			fmt.Fprintf(tw, "%s = %s\t# %s\n", name, s.format(), src)
+		} else {
+			fmt.Fprintf(tw, "%s = %s\n", name, s.format())
+		}
+	}
+	return tw.Flush()
+}
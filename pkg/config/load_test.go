@@ -0,0 +1,217 @@
+package config
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// load runs Load with a fresh flag set and env as the environment.
+func load(t *testing.T, args []string, env map[string]string) (*Config, error) {
+	t.Helper()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return Load(fs, args, func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	})
+}
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadDefaults(t *testing.T) {
+	cfg, err := load(t, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Default()
+	want.sources = map[string]string{}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("Load() = %+v, want the defaults %+v", cfg, want)
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	path := writeFile(t, `
+[server]
+addr = ":1001"
+read_timeout = "1s"
+write_timeout = "1s"
+trusted_proxies = ["10.0.0.0/8"]
+
+[log]
+level = "debug"
+`)
+	env := map[string]string{
+		FileEnv:                          path,
+		"SEATTLE_SERVER_ADDR":            ":1002",
+		"SEATTLE_SERVER_READ_TIMEOUT":    "2s",
+		"SEATTLE_RATE_LIMIT_PUBLIC_RATE": "2.5",
+		"SEATTLE_CORS_ALLOWED_ORIGINS":   "https://a.example, https://b.example",
+	}
+	cfg, err := load(t, []string{"-server.addr", ":1003", "-rate_limit.public_burst=7", "rest"}, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Server.Addr != ":1003" {
+		t.Errorf("server.addr = %q, want the flag's :1003", cfg.Server.Addr)
+	}
+	if cfg.Server.ReadTimeout != 2*time.Second {
+		t.Errorf("server.read_timeout = %v, want the environment's 2s", cfg.Server.ReadTimeout)
+	}
+	if cfg.Server.WriteTimeout != time.Second {
+		t.Errorf("server.write_timeout = %v, want the file's 1s", cfg.Server.WriteTimeout)
+	}
+	if cfg.Server.IdleTimeout != Default().Server.IdleTimeout {
+		t.Errorf("server.idle_timeout = %v, want the default", cfg.Server.IdleTimeout)
+	}
+	if want := []string{"10.0.0.0/8"}; !reflect.DeepEqual(cfg.Server.TrustedProxies, want) {
+		t.Errorf("server.trusted_proxies = %q, want %q", cfg.Server.TrustedProxies, want)
+	}
+	if want := []string{"https://a.example", "https://b.example"}; !reflect.DeepEqual(cfg.CORS.AllowedOrigins, want) {
+		t.Errorf("cors.allowed_origins = %q, want %q", cfg.CORS.AllowedOrigins, want)
+	}
+	if cfg.RateLimit.PublicRate != 2.5 || cfg.RateLimit.PublicBurst != 7 {
+		t.Errorf("rate_limit = %v/%d, want 2.5/7", cfg.RateLimit.PublicRate, cfg.RateLimit.PublicBurst)
+	}
+	if cfg.Log.Level != "debug" {
+		t.Errorf("log.level = %q, want the file's debug", cfg.Log.Level)
+	}
+
+	wantSources := map[string]string{
+		"server.addr":             "-server.addr",
+		"server.read_timeout":     "$SEATTLE_SERVER_READ_TIMEOUT",
+		"server.write_timeout":    path,
+		"server.trusted_proxies":  path,
+		"log.level":               path,
+		"rate_limit.public_rate":  "$SEATTLE_RATE_LIMIT_PUBLIC_RATE",
+		"rate_limit.public_burst": "-rate_limit.public_burst",
+		"cors.allowed_origins":    "$SEATTLE_CORS_ALLOWED_ORIGINS",
+	}
+	if !reflect.DeepEqual(cfg.sources, wantSources) {
+		t.Errorf("sources = %v, want %v", cfg.sources, wantSources)
+	}
+}
+
+func TestLoadConfigFlag(t *testing.T) {
+	fromEnv := writeFile(t, "[server]\naddr = \":1001\"\n")
+	fromFlag := writeFile(t, "[server]\naddr = \":1002\"\n")
+	cfg, err := load(t, []string{"-config", fromFlag}, map[string]string{FileEnv: fromEnv})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Server.Addr != ":1002" {
+		t.Errorf("server.addr = %q, want :1002 from the -config file", cfg.Server.Addr)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	path := writeFile(t, `
+[server]
+addr = "nope"
+bogus = 1
+static_dir = ["web"]
+trusted_proxies = ["10.0.0.0/8", "proxy.internal"]
+`)
+	_, err := load(t, []string{"-config", path, "-log.level", "loud", "-server.metrics_addr", "nope:1:2"}, map[string]string{
+		"SEATTLE_SERVER_READ_TIMEOUT": "soon",
+	})
+	if err == nil {
+		t.Fatal("Load succeeded, want errors")
+	}
+	// Every problem is reported in the same run.
+	for _, want := range []string{
+		"server.bogus (from " + path + "): unknown setting",
+		"server.static_dir (from " + path + "): expected a single value, got a list",
+		`server.read_timeout (from $SEATTLE_SERVER_READ_TIMEOUT): invalid duration "soon"`,
+		`server.addr: "nope" is not a host:port address`,
+		`log.level: "loud" is not debug, info, warn or error`,
+		`server.metrics_addr: "nope:1:2" is not a host:port address`,
+		`server.trusted_proxies: "proxy.internal" is not an address or CIDR range`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
+
+func TestPrint(t *testing.T) {
+	cfg, err := load(t, []string{"-cors.allowed_origins", "https://a.example"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	if err := cfg.Print(&b); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"[server]\naddr ",
+		`hmac_key = ""`,
+		`allowed_origins = ["https://a.example"] # -cors.allowed_origins`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Print output does not contain %q:\n%s", want, out)
+		}
+	}
+
+	// The output is a config file that reproduces the configuration.
+	cfg2, err := load(t, []string{"-config", writeFile(t, out)}, nil)
+	if err != nil {
+		t.Fatalf("loading the printed config: %v", err)
+	}
+	cfg2.sources, cfg.sources = nil, nil
+	if !reflect.DeepEqual(cfg2, cfg) {
+		t.Errorf("printed config loads as %+v, want %+v", cfg2, cfg)
+	}
+
+	key := strings.Repeat("k", 32)
+	cfg.Audit.HMACKey = key
+	b.Reset()
+	if err := cfg.Print(&b); err != nil {
+		t.Fatal(err)
+	}
+	if out := b.String(); strings.Contains(out, key) || !strings.Contains(out, `hmac_key = "[REDACTED]"`) {
+		t.Errorf("Print does not redact the secret:\n%s", out)
+	}
+}
+
+func TestLoadBoolFlags(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	env := map[string]string{"SEATTLE_DATABASE_SEED_DEMO": "true"}
+	cfg, err := Load(fs, []string{"-database.auto_migrate", "-database.seed_demo=false", "up"}, func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A bare boolean flag does not consume the next argument.
+	if !cfg.Database.AutoMigrate {
+		t.Error("bare -database.auto_migrate did not enable it")
+	}
+	if cfg.Database.SeedDemo {
+		t.Error("-database.seed_demo=false did not override the environment")
+	}
+	if args := fs.Args(); len(args) != 1 || args[0] != "up" {
+		t.Errorf("positional arguments = %q, want [up]", args)
+	}
+
+	if _, err := load(t, []string{"-database.auto_migrate=maybe"}, nil); err == nil || !strings.Contains(err.Error(), `invalid boolean "maybe"`) {
+		t.Errorf("Load with -database.auto_migrate=maybe = %v, want an invalid boolean error", err)
+	}
+}
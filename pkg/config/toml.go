@@ -0,0 +1,155 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// parseTOMLFile reads the config file at path. See parseTOML for the
+// supported syntax.
+func parseTOMLFile(path string) (map[string]any, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	defer f.Close()
+	values, err := parseTOML(f)
+	if err != nil {
+		return nil, fmt.Errorf("config: %s: %w", path, err)
+	}
+	return values, nil
+}
+
+// parseTOML decodes the subset of TOML the config file needs: [section]
+// headers, `key = value` pairs, # comments, basic and literal strings,
+// bare numbers and booleans, and single-line arrays of strings. Values are
+// returned keyed by "section.key" as strings (scalars) or []string (arrays);
+// conversion to the field's type happens later, exactly as for environment
+// variables.
+func parseTOML(r io.Reader) (map[string]any, error) {
+	values := make(map[string]any)
+	section := ""
+	sc := bufio.NewScanner(r)
+	for lineNo := 1; sc.Scan(); lineNo++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			end := strings.Index(line, "]")
+			if end < 0 || strings.TrimSpace(stripComment(line[end+1:])) != "" {
+				return nil, fmt.Errorf("line %d: malformed section header", lineNo)
+			}
+			section = strings.TrimSpace(line[1:end])
+			if section == "" {
+				return nil, fmt.Errorf("line %d: empty section name", lineNo)
+			}
+			continue
+		}
+
+		key, raw, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key = value", lineNo)
+		}
+		key = strings.TrimSpace(key)
+		if key == "" {
+			return nil, fmt.Errorf("line %d: missing key", lineNo)
+		}
+		if section != "" {
+			key = section + "." + key
+		}
+		if _, dup := values[key]; dup {
+			return nil, fmt.Errorf("line %d: %s is set twice", lineNo, key)
+		}
+		v, err := parseTOMLValue(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s: %w", lineNo, key, err)
+		}
+		values[key] = v
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func parseTOMLValue(raw string) (any, error) {
+	if strings.HasPrefix(raw, "[") {
+		// Scan item by item: a "]" inside a string or a trailing comment
+		// does not close the array.
+		items := []string{}
+		rest := strings.TrimSpace(raw[1:])
+		for !strings.HasPrefix(rest, "]") {
+			s, tail, err := parseTOMLString(rest)
+			if err != nil {
+				return nil, fmt.Errorf("array: %w", err)
+			}
+			items = append(items, s)
+			rest = strings.TrimSpace(tail)
+			switch {
+			case strings.HasPrefix(rest, ","):
+				rest = strings.TrimSpace(rest[1:])
+			case !strings.HasPrefix(rest, "]"):
+				return nil, fmt.Errorf("array: expected ',' or ']' after item")
+			}
+		}
+		if strings.TrimSpace(stripComment(rest[1:])) != "" {
+			return nil, fmt.Errorf("unexpected text after array")
+		}
+		return items, nil
+	}
+	if strings.HasPrefix(raw, `"`) || strings.HasPrefix(raw, "'") {
+		s, tail, err := parseTOMLString(raw)
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(stripComment(tail)) != "" {
+			return nil, fmt.Errorf("unexpected text after string")
+		}
+		return s, nil
+	}
+	v := strings.TrimSpace(stripComment(raw))
+	if v == "" {
+		return nil, fmt.Errorf("missing value")
+	}
+	return v, nil
+}
+
+// parseTOMLString decodes the basic ("...") or literal ('...') string at the
+// start of s and returns it with the remaining text.
+func parseTOMLString(s string) (string, string, error) {
+	switch {
+	case strings.HasPrefix(s, "'"):
+		end := strings.Index(s[1:], "'")
+		if end < 0 {
+			return "", "", fmt.Errorf("unterminated string")
+		}
+		return s[1 : end+1], s[end+2:], nil
+	case strings.HasPrefix(s, `"`):
+		for i := 1; i < len(s); i++ {
+			switch s[i] {
+			case '\\':
+				i++
+			case '"':
+				v, err := strconv.Unquote(s[:i+1])
+				if err != nil {
+					return "", "", fmt.Errorf("invalid string: %w", err)
+				}
+				return v, s[i+1:], nil
+			}
+		}
+		return "", "", fmt.Errorf("unterminated string")
+	}
+	return "", "", fmt.Errorf("expected a quoted string")
+}
+
+func stripComment(s string) string {
+	if i := strings.Index(s, "#"); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTOML(t *testing.T) {
+	const file = `
+# Top-level comment.
+top = 1
+
+[server]
+addr = ":9090"   # trailing comment
+static_dir = 'C:\web\admin'
+trusted_proxies = ["10.0.0.0/8", '192.168.0.1']
+
+[ cors ]
+allowed_origins = ["https://a.example", "https://b.example",]
+allowed_headers = []
+allow_credentials = true
+max_age = 5m # see [docs]
+methods = ["GET"] # see [docs]
+quoted = "a # b \"c\" ]"
+brackets = ["]", "[x]", "a,b"]
+`
+	got, err := parseTOML(strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"top":                    "1",
+		"server.addr":            ":9090",
+		"server.static_dir":      `C:\web\admin`,
+		"server.trusted_proxies": []string{"10.0.0.0/8", "192.168.0.1"},
+		"cors.allowed_origins":   []string{"https://a.example", "https://b.example"},
+		"cors.allowed_headers":   []string{},
+		"cors.allow_credentials": "true",
+		"cors.max_age":           "5m",
+		"cors.methods":           []string{"GET"},
+		"cors.quoted":            `a # b "c" ]`,
+		"cors.brackets":          []string{"]", "[x]", "a,b"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseTOML:\n got %#v\nwant %#v", got, want)
+	}
+}
+
+func TestParseTOMLErrors(t *testing.T) {
+	for _, tc := range []struct {
+		file, want string
+	}{
+		{"[server", "line 1: malformed section header"},
+		{"[server] x", "line 1: malformed section header"},
+		{"[]", "line 1: empty section name"},
+		{"addr", "line 1: expected key = value"},
+		{" = 1", "line 1: missing key"},
+		{"a = 1\na = 2", "line 2: a is set twice"},
+		{"a =", "line 1: a: missing value"},
+		{"a = # nothing", "line 1: a: missing value"},
+		{`a = "open`, "line 1: a: unterminated string"},
+		{`a = 'open`, "line 1: a: unterminated string"},
+		{`a = "x" y`, "line 1: a: unexpected text after string"},
+		{`a = "\q"`, "line 1: a: invalid string"},
+		{`a = ["x" "y"]`, "line 1: a: array: expected ',' or ']' after item"},
+		{`a = ["x"`, "line 1: a: array: expected ',' or ']' after item"},
+		{`a = ["x", `, "line 1: a: array: expected a quoted string"},
+		{`a = [1, 2]`, "line 1: a: array: expected a quoted string"},
+		{`a = ["x"] y`, "line 1: a: unexpected text after array"},
+	} {
+		_, err := parseTOML(strings.NewReader(tc.file))
+		if err == nil || !strings.HasPrefix(err.Error(), tc.want) {
+			t.Errorf("parseTOML(%q) = %v, want %q", tc.file, err, tc.want)
+		}
+	}
+}
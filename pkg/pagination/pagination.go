@@ -0,0 +1,367 @@
+// Package pagination pages, sorts and wraps list results for the HTTP API.
+//
+// Clients page either by number (page, page_size) or with the opaque
+// next_cursor returned in every response that has more results (cursor,
+// page_size). Cursors are keyset cursors: they record the sort key of the
+// last item served, so records created or deleted between requests do not
+// shift later pages.
+//
+// Results are ordered with sort=field,-field using only the fields a
+// resource whitelists in its Spec. The record ID is always the final
+// tie-breaker so the order is total.
+package pagination
+
+import (
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultPageSize is used when the request has no page_size.
+	DefaultPageSize = 20
+	// MaxPageSize is the largest page_size accepted.
+	MaxPageSize = 100
+)
+
+// ErrInvalid is wrapped by every error caused by bad query parameters.
+var ErrInvalid = errors.New("pagination: invalid parameter")
+
+// Order sorts by one field.
+type Order struct {
+	Field string
+	Desc  bool
+}
+
+func (o Order) String() string {
+	if o.Desc {
+		return "-" + o.Field
+	}
+	return o.Field
+}
+
+// Request holds the parsed paging and sorting parameters.
+type Request struct {
+	Page     int
+	PageSize int
+	Sort     []Order
+	// after is the decoded cursor parameter. When set, it replaces Page.
+	after *cursor
+}
+
+// Info describes the page served.
+type Info struct {
+	CurrentPage  int    `json:"current_page"`
+	PageSize     int    `json:"page_size"`
+	TotalRecords int    `json:"total_records"`
+	TotalPages   int    `json:"total_pages"`
+	NextCursor   string `json:"next_cursor,omitempty"`
+}
+
+// Envelope is the response body of every list endpoint.
+type Envelope[T any] struct {
+	Data       []T  `json:"data"`
+	Pagination Info `json:"pagination"`
+}
+
+// Spec describes how a resource may be sorted.
+type Spec[T any] struct {
+	// Fields maps each sortable field name to a function returning its
+	// value, which must be a string, bool, int, int64, float64 or
+	// time.Time.
+	Fields map[string]func(T) any
+	// ID returns the record's unique ID, the final tie-breaker.
+	ID func(T) string
+	// DefaultSort is used when the request has no sort parameter.
+	DefaultSort []Order
+}
+
+// Validate reports the mistakes in s that would make Apply misbehave: a
+// field whose values are not of a supported type, a default sort field that
+// is not in Fields, or a missing ID.
+func (s Spec[T]) Validate() error {
+	var errs []error
+	if s.ID == nil {
+		errs = append(errs, errors.New("pagination: spec has no ID function"))
+	}
+	var zero T
+	for _, name := range s.fieldNames() {
+		if v := s.Fields[name](zero); !supported(v) {
+			errs = append(errs, fmt.Errorf("pagination: field %q has unsupported type %T", name, v))
+		}
+	}
+	for _, o := range s.DefaultSort {
+		if _, ok := s.Fields[o.Field]; !ok {
+			errs = append(errs, fmt.Errorf("pagination: default sort field %q is not sortable", o.Field))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Must returns s and panics if it is invalid. It is meant for package-level
+// Specs, so that a mistake stops the server at startup rather than failing
+// requests.
+func Must[T any](s Spec[T]) Spec[T] {
+	if err := s.Validate(); err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func supported(v any) bool {
+	switch v.(type) {
+	case string, bool, int, int64, float64, time.Time:
+		return true
+	}
+	return false
+}
+
+// cursor is the decoded form of an opaque cursor.
+type cursor struct {
+	Sort string            `json:"s"`
+	Keys []json.RawMessage `json:"k"`
+	ID   string            `json:"id"`
+}
+
+// Parse reads page, page_size, sort and cursor from q.
+func (s Spec[T]) Parse(q url.Values) (Request, error) {
+	req := Request{Page: 1, PageSize: DefaultPageSize, Sort: s.DefaultSort}
+	var err error
+	if v := q.Get("page"); v != "" {
+		if req.Page, err = strconv.Atoi(v); err != nil || req.Page < 1 {
+			return req, fmt.Errorf("%w: page must be a positive integer", ErrInvalid)
+		}
+	}
+	if v := q.Get("page_size"); v != "" {
+		if req.PageSize, err = strconv.Atoi(v); err != nil || req.PageSize < 1 || req.PageSize > MaxPageSize {
+			return req, fmt.Errorf("%w: page_size must be between 1 and %d", ErrInvalid, MaxPageSize)
+		}
+	}
+	if v := q.Get("sort"); v != "" {
+		req.Sort = nil
+		for _, f := range strings.Split(v, ",") {
+			o := Order{Field: strings.TrimSpace(f)}
+			if o.Field, o.Desc = strings.CutPrefix(o.Field, "-"); o.Desc {
+				o.Field = strings.TrimSpace(o.Field)
+			}
+			if _, ok := s.Fields[o.Field]; !ok {
+				return req, fmt.Errorf("%w: cannot sort by %q; allowed fields: %s", ErrInvalid, o.Field, strings.Join(s.fieldNames(), ", "))
+			}
+			req.Sort = append(req.Sort, o)
+		}
+	}
+	if v := q.Get("cursor"); v != "" {
+		if q.Has("page") {
+			return req, fmt.Errorf("%w: page and cursor cannot be combined", ErrInvalid)
+		}
+		c, err := s.decodeCursor(v)
+		if err != nil {
+			return req, err
+		}
+		if q.Has("sort") && c.Sort != sortString(req.Sort) {
+			return req, fmt.Errorf("%w: sort does not match the cursor", ErrInvalid)
+		}
+		req.Sort = parseSortString(c.Sort)
+		req.after = c
+	}
+	return req, nil
+}
+
+// Apply sorts items and returns the requested page of them. items is not
+// modified.
+func (s Spec[T]) Apply(items []T, req Request) (Envelope[T], error) {
+	sorted := slices.Clone(items)
+	slices.SortStableFunc(sorted, func(a, b T) int {
+		for _, o := range req.Sort {
+			f := s.Fields[o.Field]
+			if c := compare(f(a), f(b)); c != 0 {
+				if o.Desc {
+					return -c
+				}
+				return c
+			}
+		}
+		return strings.Compare(s.ID(a), s.ID(b))
+	})
+
+	start := (req.Page - 1) * req.PageSize
+	if req.after != nil {
+		keys, err := s.cursorKeys(req.after, req.Sort)
+		if err != nil {
+			return Envelope[T]{}, err
+		}
+		start, _ = slices.BinarySearchFunc(sorted, req.after.ID, func(item T, id string) int {
+			for i, o := range req.Sort {
+				if c := compare(s.Fields[o.Field](item), keys[i]); c != 0 {
+					if o.Desc {
+						return -c
+					}
+					return c
+				}
+			}
+			// Step past the item the cursor points at.
+			if c := strings.Compare(s.ID(item), id); c != 0 {
+				return c
+			}
+			return -1
+		})
+	}
+	start = min(start, len(sorted))
+	end := min(start+req.PageSize, len(sorted))
+
+	env := Envelope[T]{
+		Data: sorted[start:end],
+		Pagination: Info{
+			CurrentPage:  start/req.PageSize + 1,
+			PageSize:     req.PageSize,
+			TotalRecords: len(sorted),
+			TotalPages:   max(1, (len(sorted)+req.PageSize-1)/req.PageSize),
+		},
+	}
+	if end < len(sorted) && end > start {
+		env.Pagination.NextCursor = s.encodeCursor(sorted[end-1], req.Sort)
+	}
+	return env, nil
+}
+
+func (s Spec[T]) fieldNames() []string {
+	names := make([]string, 0, len(s.Fields))
+	for name := range s.Fields {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func (s Spec[T]) encodeCursor(last T, sort []Order) string {
+	c := cursor{Sort: sortString(sort), ID: s.ID(last)}
+	for _, o := range sort {
+		key, _ := json.Marshal(s.Fields[o.Field](last))
+		c.Keys = append(c.Keys, key)
+	}
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func (s Spec[T]) decodeCursor(v string) (*cursor, error) {
+	invalid := fmt.Errorf("%w: malformed cursor", ErrInvalid)
+	data, err := base64.RawURLEncoding.DecodeString(v)
+	if err != nil {
+		return nil, invalid
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, invalid
+	}
+	for _, o := range parseSortString(c.Sort) {
+		if _, ok := s.Fields[o.Field]; !ok {
+			return nil, invalid
+		}
+	}
+	return &c, nil
+}
+
+// cursorKeys decodes the cursor's sort keys into the types the fields
+// produce, found by evaluating each field on the zero value of T.
+func (s Spec[T]) cursorKeys(c *cursor, sort []Order) ([]any, error) {
+	if len(c.Keys) != len(sort) {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalid)
+	}
+	var zero T
+	keys := make([]any, len(sort))
+	for i, o := range sort {
+		var err error
+		switch s.Fields[o.Field](zero).(type) {
+		case string:
+			keys[i], err = decodeAs[string](c.Keys[i])
+		case bool:
+			keys[i], err = decodeAs[bool](c.Keys[i])
+		case int:
+			keys[i], err = decodeAs[int](c.Keys[i])
+		case int64:
+			keys[i], err = decodeAs[int64](c.Keys[i])
+		case float64:
+			keys[i], err = decodeAs[float64](c.Keys[i])
+		case time.Time:
+			keys[i], err = decodeAs[time.Time](c.Keys[i])
+		default:
+			return nil, fmt.Errorf("pagination: field %q has an unsupported type", o.Field)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: malformed cursor", ErrInvalid)
+		}
+	}
+	return keys, nil
+}
+
+func decodeAs[V any](raw json.RawMessage) (V, error) {
+	var v V
+	err := json.Unmarshal(raw, &v)
+	return v, err
+}
+
+// compare orders two values produced by the same field. Values of a type
+// Validate rejects, or of differing types, compare equal.
+func compare(a, b any) int {
+	switch a := a.(type) {
+	case string:
+		if b, ok := b.(string); ok {
+			return strings.Compare(a, b)
+		}
+	case bool:
+		if b, ok := b.(bool); ok {
+			switch {
+			case a == b:
+				return 0
+			case b:
+				return -1
+			}
+			return 1
+		}
+	case int:
+		if b, ok := b.(int); ok {
+			return cmp.Compare(a, b)
+		}
+	case int64:
+		if b, ok := b.(int64); ok {
+			return cmp.Compare(a, b)
+		}
+	case float64:
+		if b, ok := b.(float64); ok {
+			return cmp.Compare(a, b)
+		}
+	case time.Time:
+		if b, ok := b.(time.Time); ok {
+			return a.Compare(b)
+		}
+	}
+	return 0
+}
+
+func sortString(sort []Order) string {
+	parts := make([]string, len(sort))
+	for i, o := range sort {
+		parts[i] = o.String()
+	}
+	return strings.Join(parts, ",")
+}
+
+func parseSortString(v string) []Order {
+	var sort []Order
+	for _, f := range strings.Split(v, ",") {
+		if f == "" {
+			continue
+		}
+		o := Order{Field: f}
+		o.Field, o.Desc = strings.CutPrefix(f, "-")
+		sort = append(sort, o)
+	}
+	return sort
+}
@@ -0,0 +1,242 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+type item struct {
+	ID    string
+	Name  string
+	Seq   int64
+	Score float64
+	At    time.Time
+}
+
+var itemSpec = Spec[item]{
+	Fields: map[string]func(item) any{
+		"name":  func(i item) any { return i.Name },
+		"seq":   func(i item) any { return i.Seq },
+		"score": func(i item) any { return i.Score },
+		"at":    func(i item) any { return i.At },
+	},
+	ID:          func(i item) string { return i.ID },
+	DefaultSort: []Order{{Field: "seq", Desc: true}},
+}
+
+func TestValidate(t *testing.T) {
+	if err := itemSpec.Validate(); err != nil {
+		t.Errorf("valid spec: %v", err)
+	}
+
+	bad := Spec[item]{
+		Fields: map[string]func(item) any{
+			"seq":  func(i item) any { return int32(i.Seq) },
+			"name": func(i item) any { return i.Name },
+		},
+		DefaultSort: []Order{{Field: "missing"}},
+	}
+	err := bad.Validate()
+	if err == nil {
+		t.Fatal("invalid spec passed Validate")
+	}
+	for _, want := range []string{"no ID function", `field "seq" has unsupported type int32`, `default sort field "missing"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate error %q does not mention %q", err, want)
+		}
+	}
+}
+
+func items(n int) []item {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	out := make([]item, n)
+	for i := range out {
+		out[i] = item{
+			ID:    fmt.Sprintf("id%02d", i),
+			Name:  fmt.Sprintf("name%d", i%3), // Ties, broken by ID
+			Seq:   int64(i),
+			Score: float64(i % 4),
+			At:    base.Add(time.Duration(i) * time.Hour),
+		}
+	}
+	return out
+}
+
+func ids(list []item) []string {
+	out := make([]string, len(list))
+	for i, it := range list {
+		out[i] = it.ID
+	}
+	return out
+}
+
+func mustParse(t *testing.T, query string) Request {
+	t.Helper()
+	q, err := url.ParseQuery(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := itemSpec.Parse(q)
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", query, err)
+	}
+	return req
+}
+
+func TestParse(t *testing.T) {
+	req := mustParse(t, "")
+	if req.Page != 1 || req.PageSize != DefaultPageSize || sortString(req.Sort) != "-seq" {
+		t.Errorf("defaults: %+v", req)
+	}
+	req = mustParse(t, "page=3&page_size=7&sort=name,+-at")
+	if req.Page != 3 || req.PageSize != 7 || sortString(req.Sort) != "name,-at" {
+		t.Errorf("explicit: %+v", req)
+	}
+
+	for _, query := range []string{
+		"page=0", "page=x", "page_size=0", "page_size=101", "sort=missing", "sort=name,",
+	} {
+		q, _ := url.ParseQuery(query)
+		if _, err := itemSpec.Parse(q); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Parse(%q) = %v, want ErrInvalid", query, err)
+		}
+	}
+}
+
+func TestPages(t *testing.T) {
+	all := items(10)
+	env, err := itemSpec.Apply(all, mustParse(t, "page=2&page_size=4&sort=name"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Page 1 holds the four name0 items; ties are broken by ID.
+	want := []string{"id01", "id04", "id07", "id02"}
+	if got := ids(env.Data); !slices.Equal(got, want) {
+		t.Errorf("page 2 = %v, want %v", got, want)
+	}
+	if p := env.Pagination; p.CurrentPage != 2 || p.TotalRecords != 10 || p.TotalPages != 3 || p.NextCursor == "" {
+		t.Errorf("info %+v", p)
+	}
+
+	env, _ = itemSpec.Apply(all, mustParse(t, "page=9"))
+	if len(env.Data) != 0 || env.Pagination.NextCursor != "" {
+		t.Errorf("past the end: %+v", env)
+	}
+}
+
+// walk pages through all with cursors, calling between before each
+// following page, and returns the IDs served.
+func walk(t *testing.T, all []item, query string, between func([]item) []item) []string {
+	t.Helper()
+	var served []string
+	req := mustParse(t, query)
+	for i := 0; i < 100; i++ {
+		env, err := itemSpec.Apply(all, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		served = append(served, ids(env.Data)...)
+		if env.Pagination.NextCursor == "" {
+			return served
+		}
+		all = between(all)
+		req = mustParse(t, "page_size=3&cursor="+env.Pagination.NextCursor)
+	}
+	t.Fatal("cursor never ended")
+	return nil
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	for _, sort := range []string{"-seq", "name", "-score,at", "at"} {
+		all := items(10)
+		want := ids(mustApplyAll(t, all, sort))
+		got := walk(t, all, "page_size=3&sort="+sort, func(list []item) []item { return list })
+		if !slices.Equal(got, want) {
+			t.Errorf("sort %s: served %v, want %v", sort, got, want)
+		}
+	}
+}
+
+func mustApplyAll(t *testing.T, all []item, sort string) []item {
+	t.Helper()
+	env, err := itemSpec.Apply(all, mustParse(t, "page_size=100&sort="+sort))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return env.Data
+}
+
+func TestCursorStableAcrossChanges(t *testing.T) {
+	// Sorted by -seq, newest first. Between pages a newer item is
+	// inserted, which belongs before the pages already served, and an
+	// item that was already served is deleted; neither shifts the rest.
+	all := items(10)
+	next := int64(100)
+	got := walk(t, all, "page_size=3&sort=-seq", func(list []item) []item {
+		list = append(list, item{ID: fmt.Sprintf("new%d", next), Seq: next})
+		next++
+		return slices.DeleteFunc(list, func(it item) bool { return it.ID == "id09" })
+	})
+	want := []string{"id09", "id08", "id07", "id06", "id05", "id04", "id03", "id02", "id01", "id00"}
+	if !slices.Equal(got, want) {
+		t.Errorf("served %v, want %v", got, want)
+	}
+
+	// Deleting the very item the cursor points at does not lose its place.
+	all = items(10)
+	env, _ := itemSpec.Apply(all, mustParse(t, "page_size=3&sort=-seq"))
+	last := env.Data[len(env.Data)-1].ID
+	all = slices.DeleteFunc(all, func(it item) bool { return it.ID == last })
+	env, _ = itemSpec.Apply(all, mustParse(t, "page_size=3&cursor="+env.Pagination.NextCursor))
+	if got := ids(env.Data); !slices.Equal(got, []string{"id06", "id05", "id04"}) {
+		t.Errorf("after deleting the cursor item: %v", got)
+	}
+}
+
+func TestCursorRejected(t *testing.T) {
+	env, _ := itemSpec.Apply(items(10), mustParse(t, "page_size=3&sort=name"))
+	cursor := env.Pagination.NextCursor
+
+	forged := func(c string) string { return base64.RawURLEncoding.EncodeToString([]byte(c)) }
+	tests := []struct {
+		name, query string
+	}{
+		{"combined with page", "page=2&cursor=" + cursor},
+		{"different sort", "sort=-name&cursor=" + cursor},
+		{"not base64", "cursor=***"},
+		{"not JSON", "cursor=" + forged("nope")},
+		{"unknown sort field", "cursor=" + forged(`{"s":"secret","k":["x"],"id":"a"}`)},
+	}
+	for _, tt := range tests {
+		q, _ := url.ParseQuery(tt.query)
+		if _, err := itemSpec.Parse(q); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%s: Parse = %v, want ErrInvalid", tt.name, err)
+		}
+	}
+
+	// The same sort, spelled out, is accepted.
+	if _, err := itemSpec.Parse(url.Values{"sort": {"name"}, "cursor": {cursor}}); err != nil {
+		t.Errorf("matching sort: %v", err)
+	}
+
+	// Keys that do not fit the sort are caught when the page is built.
+	for _, c := range []string{
+		`{"s":"name","k":[],"id":"a"}`,
+		`{"s":"seq","k":["not a number"],"id":"a"}`,
+		`{"s":"at","k":[12],"id":"a"}`,
+	} {
+		req, err := itemSpec.Parse(url.Values{"cursor": {forged(c)}})
+		if err != nil {
+			t.Fatalf("%s: %v", c, err)
+		}
+		if _, err := itemSpec.Apply(items(3), req); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%s: Apply = %v, want ErrInvalid", c, err)
+		}
+	}
+}
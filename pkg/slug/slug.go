@@ -0,0 +1,96 @@
+// Package slug turns human-readable names into URL path segments.
+//
+// A slug contains only ASCII lowercase letters, digits and single dashes,
+// never starting or ending with a dash. Latin letters with diacritics and
+// common ligatures are transliterated ("Café" → "cafe", "Straße" →
+// "strasse"), also when the diacritic is a separate combining mark, and so
+// are Cyrillic and Greek letters ("Москва" → "moskva"). Letters and digits
+// of other scripts are dropped like any other character, which separates
+// words.
+package slug
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Fallback is returned by Make for names that contain no letters or digits.
+const Fallback = "n-a"
+
+// translit maps non-ASCII letters to their closest ASCII spelling.
+var translit = map[rune]string{
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'ā': "a", 'ă': "a", 'ą': "a",
+	'æ': "ae", 'ç': "c", 'ć': "c", 'č': "c", 'ď': "d", 'đ': "d", 'ð': "d",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ē': "e", 'ė': "e", 'ę': "e", 'ě': "e",
+	'ğ': "g", 'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ī': "i", 'į': "i", 'ı': "i",
+	'ł': "l", 'ľ': "l", 'ñ': "n", 'ń': "n", 'ň': "n",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'ō': "o", 'ő': "o", 'œ': "oe",
+	'ř': "r", 'ß': "ss", 'ś': "s", 'š': "s", 'ş': "s", 'ș': "s", 'ť': "t", 'ţ': "t", 'ț': "t", 'þ': "th",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ū': "u", 'ů': "u", 'ű': "u", 'ų': "u",
+	'ý': "y", 'ÿ': "y", 'ź': "z", 'ż': "z", 'ž': "z",
+
+	// Cyrillic, after common Russian and Ukrainian romanization. The hard
+	// and soft signs have no spelling of their own.
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'ґ': "g", 'д': "d", 'е': "e", 'ё': "e", 'є': "ye",
+	'ж': "zh", 'з': "z", 'и': "i", 'і': "i", 'ї': "yi", 'й': "y", 'к': "k", 'л': "l", 'м': "m",
+	'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ў': "u", 'ф': "f",
+	'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "",
+	'э': "e", 'ю': "yu", 'я': "ya",
+
+	// Greek, after ELOT 743.
+	'α': "a", 'ά': "a", 'β': "v", 'γ': "g", 'δ': "d", 'ε': "e", 'έ': "e", 'ζ': "z",
+	'η': "i", 'ή': "i", 'θ': "th", 'ι': "i", 'ί': "i", 'ϊ': "i", 'ΐ': "i", 'κ': "k",
+	'λ': "l", 'μ': "m", 'ν': "n", 'ξ': "x", 'ο': "o", 'ό': "o", 'π': "p", 'ρ': "r",
+	'σ': "s", 'ς': "s", 'τ': "t", 'υ': "y", 'ύ': "y", 'ϋ': "y", 'ΰ': "y", 'φ': "f",
+	'χ': "ch", 'ψ': "ps", 'ω': "o", 'ώ': "o",
+}
+
+// Make returns the slug for name.
+func Make(name string) string {
+	var b strings.Builder
+	dash := false // a separator is pending
+	for _, r := range strings.ToLower(name) {
+		ascii, known := translit[r]
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			ascii, known = string(r), true
+		case unicode.In(r, unicode.Mn, unicode.Mc):
+			// Combining marks belong to the letter before them, so
+			// decomposed "é" becomes "e" like the composed one.
+			continue
+		case r == '\'' || r == '’':
+			// Apostrophes join rather than split words: "Joe's" → "joes".
+			continue
+		}
+		if !known {
+			dash = true
+			continue
+		}
+		if ascii == "" {
+			continue
+		}
+		if dash && b.Len() > 0 {
+			b.WriteByte('-')
+		}
+		dash = false
+		b.WriteString(ascii)
+	}
+	if b.Len() == 0 {
+		return Fallback
+	}
+	return b.String()
+}
+
+// Unique returns base if it is not taken, otherwise the first of base-2,
+// base-3, … that is free.
+func Unique(base string, taken func(string) bool) string {
+	if !taken(base) {
+		return base
+	}
+	for n := 2; ; n++ {
+		if s := base + "-" + strconv.Itoa(n); !taken(s) {
+			return s
+		}
+	}
+}
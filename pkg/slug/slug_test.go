@@ -0,0 +1,56 @@
+package slug
+
+import (
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+var valid = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+func TestMake(t *testing.T) {
+	tests := []struct{ name, want string }{
+		{"Used Cars", "used-cars"},
+		{"  --Jobs & Gigs--  ", "jobs-gigs"},
+		{"Joe's Café", "joes-cafe"},
+		{"Straße", "strasse"},
+		{"Œuvres d’art", "oeuvres-dart"},
+		{"Room 101", "room-101"},
+		// Decomposed (NFD) accents spell the same as composed ones.
+		{"Re\u0301sume\u0301", "resume"},
+		{"Re\u0301sume\u0301 Help", "resume-help"},
+		{"Ca\u0308fe\u0301", "cafe"},
+		// Cyrillic and Greek are transliterated.
+		{"Москва", "moskva"},
+		{"Подъезд Київ", "podezd-kiyiv"},
+		{"Ελληνικά!", "ellinika"},
+		// Other scripts and non-ASCII digits are dropped.
+		{"東京 Tokyo", "tokyo"},
+		{"Tokyo 東京 Tower", "tokyo-tower"},
+		{"हिन्दी", Fallback},
+		{"Room ٣", "room"},
+		// Names without letters or digits fall back.
+		{"", Fallback},
+		{"!!!", Fallback},
+		{"\u0301", Fallback},
+	}
+	for _, tt := range tests {
+		got := Make(tt.name)
+		if got != tt.want {
+			t.Errorf("Make(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+		if !valid.MatchString(got) {
+			t.Errorf("Make(%q) = %q, not [a-z0-9-]", tt.name, got)
+		}
+	}
+}
+
+func TestUnique(t *testing.T) {
+	taken := map[string]bool{"jobs": true, "jobs-2": true, "jobs-3": true}
+	if got := Unique("cars", func(s string) bool { return taken[s] }); got != "cars" {
+		t.Errorf("free slug: %q", got)
+	}
+	if got := Unique("jobs", func(s string) bool { return taken[s] }); got != "jobs-"+strconv.Itoa(4) {
+		t.Errorf("taken slug: %q", got)
+	}
+}